@@ -22,6 +22,8 @@ package gonav
 import (
 	"container/heap"
 	"errors"
+	"fmt"
+	"math"
 )
 
 // MeshConnectionCalculator is a func that calculates the cost of a connection in a nav mesh
@@ -37,10 +39,23 @@ type HeuristicCalculator func(*NavArea, *NavArea) float32
 
 // PathNode is a single node along a path
 type PathNode struct {
-	Area               *NavArea
-	PrevNode           *PathNode
-	CostFromStart      float32
-	estimatedCostToEnd float32
+	Area               *NavArea           // The area this node is in
+	PrevNode           *PathNode          // The node before this one; nil for the first node
+	Connection         *NavConnection     // The connection walked from the previous node to get here; nil if a ladder was taken
+	Ladder             *NavLadder         // The ladder taken from the previous node to get here; nil if it was walked
+	LadderDirection    NavLadderDirection // The direction Ladder was taken in
+	Entry              Vector3            // The point the path enters Area at, or where it starts for the first node; only set by FindPath
+	CostFromStart      float32            // The cost of the path from the start to this node
+	estimatedCostToEnd float32            // CostFromStart plus the estimated cost from here to the end
+}
+
+// String describes how this node was reached, e.g. "area 42 via ladder 12 up"
+func (node *PathNode) String() string {
+	if node.Ladder != nil {
+		return fmt.Sprintf("area %v via ladder %v %v", node.Area.ID, node.Ladder.ID, node.LadderDirection)
+	}
+
+	return fmt.Sprintf("area %v", node.Area.ID)
 }
 
 // Path represents a path between two points
@@ -92,21 +107,46 @@ func SimpleBuildShortestPath(startArea, endArea *NavArea) (Path, error) {
 
 // BuildShortestPath builds a path (via PathFinding A*) and returns a Path object containing the start and end nodes of the path
 // startArea and endArea are the starting and ending NavAreas for the path
-// areaCostCalc is a func() that calculates the "cost" of a connection between two NavAreas
-// ladderCostCalc is a func() that calculates the "cost" of a connection via a ladder
+// areaCostCalc is a func() that calculates the "cost" of a connection between two NavAreas; +Inf forbids the connection
+// ladderCostCalc is a func() that calculates the "cost" of a connection via a ladder; +Inf forbids the connection
 // heurisiticCost is a func() that estimates an admissible AND monotonic cost for two (likely nonadjacent) NavAreas
 func BuildShortestPath(startArea, endArea *NavArea, areaCostCalc MeshConnectionCalculator, ladderCostCalc MeshLadderCalculator, heurisiticCost HeuristicCalculator) (Path, error) {
+	return buildShortestPath(startArea, endArea, Vector3{}, pathCosts{
+		connection: func(from *PathNode, con *NavConnection, entry Vector3) float32 {
+			return areaCostCalc(con)
+		},
+		ladder: func(from *PathNode, ladder *NavLadder, direction NavLadderDirection, to *NavArea, entry Vector3) float32 {
+			return ladderCostCalc(ladder, direction, from.Area, to)
+		},
+		heuristic: func(area *NavArea, entry Vector3) float32 {
+			return heurisiticCost(area, endArea)
+		}})
+}
+
+// pathCosts holds the funcs buildShortestPath weighs a path with. If entries is set, each is given the point the path
+// would enter the next area at, so the cost can depend on where in the areas the path actually goes; otherwise
+// the entry points aren't computed and the funcs are given the zero Vector3.
+type pathCosts struct {
+	entries    bool                                                                                                      // Whether the funcs use the entry points
+	connection func(from *PathNode, con *NavConnection, entry Vector3) float32                                           // The cost of a connection; +Inf forbids it
+	ladder     func(from *PathNode, ladder *NavLadder, direction NavLadderDirection, to *NavArea, entry Vector3) float32 // The cost of a ladder; +Inf forbids it
+	heuristic  func(area *NavArea, entry Vector3) float32                                                                // The admissible AND monotonic estimated cost to the end
+}
+
+// buildShortestPath builds a path (via PathFinding A*) from startArea, which the path enters at start, to endArea
+func buildShortestPath(startArea, endArea *NavArea, start Vector3, costs pathCosts) (Path, error) {
 	closedSet := make(map[*NavArea]bool)
 	nodeLookup := make(map[*NavArea]*queueItem)
 	openSet := make(priorityQueue, 0)
 	heap.Init(&openSet)
 
-	start := PathNode{
+	startNode := PathNode{
 		Area:               startArea,
+		Entry:              start,
 		CostFromStart:      0,
-		estimatedCostToEnd: heurisiticCost(startArea, endArea)}
+		estimatedCostToEnd: costs.heuristic(startArea, start)}
 
-	nodeLookup[startArea] = openSet.CreateAndPush(&start)
+	nodeLookup[startArea] = openSet.CreateAndPush(&startNode)
 
 	for openSet.Len() > 0 {
 		currentNode := openSet.PopCast()
@@ -120,12 +160,27 @@ func BuildShortestPath(startArea, endArea *NavArea, areaCostCalc MeshConnectionC
 
 		// Look at all the places connected to where we are
 		for _, currConnection := range currentNode.Area.Connections {
+			if currConnection.TargetArea == nil {
+				continue // Dangling area ID
+			}
+
 			if closedSet[currConnection.TargetArea] {
 				continue // We've been here before
 			}
 
 			// Calculate the cost to get there from here
-			newCost := currentNode.CostFromStart + areaCostCalc(currConnection)
+			var entry Vector3
+			if costs.entries {
+				entry = currConnection.entryFrom(currentNode.Entry)
+			}
+
+			connectionCost := costs.connection(currentNode, currConnection, entry)
+
+			if math.IsInf(float64(connectionCost), 1) {
+				continue // We're not allowed to go there
+			}
+
+			newCost := currentNode.CostFromStart + connectionCost
 			item := nodeLookup[currConnection.TargetArea]
 			var currNode *PathNode
 
@@ -141,8 +196,11 @@ func BuildShortestPath(startArea, endArea *NavArea, areaCostCalc MeshConnectionC
 
 			// Either this is a new place to go, or we found a better way to get there. Update.
 			currNode.PrevNode = currentNode
+			currNode.Connection = currConnection
+			currNode.Ladder = nil
+			currNode.Entry = entry
 			currNode.CostFromStart = newCost
-			currNode.estimatedCostToEnd = newCost + heurisiticCost(currNode.Area, endArea)
+			currNode.estimatedCostToEnd = newCost + costs.heuristic(currNode.Area, entry)
 
 			if item != nil {
 				openSet.update(item)
@@ -152,41 +210,32 @@ func BuildShortestPath(startArea, endArea *NavArea, areaCostCalc MeshConnectionC
 		}
 
 		// What about the places we're connected to via ladders
+		// The connection says which way this area uses the ladder; the ladder's own direction doesn't matter
 		for _, currLadderCon := range currentNode.Area.LadderConnections {
 			currLadder := currLadderCon.TargetLadder
-			var ladderAreas []*NavArea
-
-			switch currLadder.Direction {
-			case NavLadderDirectionUp:
-				if currLadder.TopBehindArea != nil {
-					ladderAreas = append(ladderAreas, currLadder.TopBehindArea)
-				}
-
-				if currLadder.TopForwardArea != nil {
-					ladderAreas = append(ladderAreas, currLadder.TopForwardArea)
-				}
+			if currLadder == nil {
+				continue // Dangling ladder ID
+			}
 
-				if currLadder.TopRightArea != nil {
-					ladderAreas = append(ladderAreas, currLadder.TopRightArea)
+			for _, currArea := range currLadder.GetDestinations(currLadderCon.Direction) {
+				if closedSet[currArea] || currArea == currentNode.Area {
+					continue // We've been here before
 				}
 
-				if currLadder.TopLeftArea != nil {
-					ladderAreas = append(ladderAreas, currLadder.TopLeftArea)
+				// Calculate the cost to get there from here
+				var entry Vector3
+				if costs.entries {
+					_, exit := currLadder.ends(currLadderCon.Direction)
+					entry = currArea.GetClosestPointInArea(exit)
 				}
 
-			case NavLadderDirectionDown:
-				if currLadder.BottomArea != nil {
-					ladderAreas = append(ladderAreas, currLadder.BottomArea)
-				}
-			}
+				ladderCost := costs.ladder(currentNode, currLadder, currLadderCon.Direction, currArea, entry)
 
-			for _, currArea := range ladderAreas {
-				if closedSet[currArea] {
-					continue // We've been here before
+				if math.IsInf(float64(ladderCost), 1) {
+					continue // We're not allowed to go there
 				}
 
-				// Calculate the cost to get there from here
-				newCost := currentNode.CostFromStart + ladderCostCalc(currLadder, currLadder.Direction, currentNode.Area, currArea)
+				newCost := currentNode.CostFromStart + ladderCost
 				item := nodeLookup[currArea]
 				var currNode *PathNode
 
@@ -202,8 +251,12 @@ func BuildShortestPath(startArea, endArea *NavArea, areaCostCalc MeshConnectionC
 
 				// Either this is a new place to go, or we found a better way to get there. Update.
 				currNode.PrevNode = currentNode
+				currNode.Connection = nil
+				currNode.Ladder = currLadder
+				currNode.LadderDirection = currLadderCon.Direction
+				currNode.Entry = entry
 				currNode.CostFromStart = newCost
-				currNode.estimatedCostToEnd = newCost + heurisiticCost(currNode.Area, endArea)
+				currNode.estimatedCostToEnd = newCost + costs.heuristic(currNode.Area, entry)
 
 				if item != nil {
 					openSet.update(item)
@@ -0,0 +1,88 @@
+/*
+	gonav - A Source Engine navigation mesh file parser written in Go.
+	Copyright (C) 2016  Matt Razza
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as published
+	by the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package gonav provides functionality related to CS:GO Nav Meshes
+package gonav
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestBuildShortestPathSkipsDanglingConnections(t *testing.T) {
+	parser := Parser{Reader: bytes.NewReader(writeTestMesh(t, newTestMesh(3), 16))}
+	mesh, err := parser.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := mesh.Areas[1]
+	start.Connections = append([]*NavConnection{{SourceArea: start, TargetAreaID: 999}}, start.Connections...)
+
+	path, err := SimpleBuildShortestPath(start, mesh.Areas[9])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(path.Nodes) != 5 {
+		t.Errorf("Expected a path through 5 areas, got %v.", path.Nodes)
+	}
+}
+
+func TestBuildShortestPathClimbsLaddersBothWays(t *testing.T) {
+	parser := Parser{Reader: bytes.NewReader(writeTestMesh(t, newTestMesh(3), 16))}
+	mesh, err := parser.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Forbid walking so the ladder between the corners is the only way across
+	walk := func(con *NavConnection) float32 { return float32(math.Inf(1)) }
+	climb := func(ladder *NavLadder, direction NavLadderDirection, start *NavArea, end *NavArea) float32 {
+		return ladder.Length
+	}
+	heuristic := func(start *NavArea, end *NavArea) float32 { return 0 }
+
+	for _, test := range []struct {
+		from, to  uint32
+		direction NavLadderDirection
+	}{
+		{1, 9, NavLadderDirectionUp},
+		{9, 1, NavLadderDirectionDown},
+	} {
+		path, err := BuildShortestPath(mesh.Areas[test.from], mesh.Areas[test.to], walk, climb, heuristic)
+		if err != nil {
+			t.Fatalf("From %v to %v: %v", test.from, test.to, err)
+		}
+
+		if len(path.Nodes) != 2 {
+			t.Fatalf("From %v to %v: expected 2 nodes, got %v.", test.from, test.to, path.Nodes)
+		}
+
+		node := path.Nodes[1]
+		if node.Ladder != mesh.Ladders[1] || node.LadderDirection != test.direction || node.Connection != nil {
+			t.Errorf("From %v to %v: expected ladder 1 %v, got %v.", test.from, test.to, test.direction, node)
+		}
+
+		if expected := fmt.Sprintf("area %v via ladder 1 %v", test.to, test.direction); node.String() != expected {
+			t.Errorf("From %v to %v: node is described as %q, expected %q.", test.from, test.to, node.String(), expected)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+/*
+	gonav - A Source Engine navigation mesh file parser written in Go.
+	Copyright (C) 2016  Matt Razza
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as published
+	by the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package gonav provides functionality related to CS:GO Nav Meshes
+package gonav
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// decoderBufferSize is large enough to hold any place name so strings never need a second buffer
+const decoderBufferSize int = 64 << 10
+
+// decoder is a buffered little-endian cursor over the input of a Parser.
+// It tracks the byte offset and refuses to read past limit (if non-zero).
+type decoder struct {
+	reader *bufio.Reader
+	offset int64
+	limit  int64
+}
+
+func newDecoder(r io.Reader, limit int64) *decoder {
+	return &decoder{reader: bufio.NewReaderSize(r, decoderBufferSize), limit: limit}
+}
+
+// Read implements io.Reader so custom data decoders can share the cursor
+func (d *decoder) Read(data []byte) (int, error) {
+	if d.limit > 0 {
+		if d.offset >= d.limit {
+			return 0, ErrLimitExceeded
+		}
+
+		if remaining := d.limit - d.offset; int64(len(data)) > remaining {
+			data = data[:remaining]
+		}
+	}
+
+	n, err := d.reader.Read(data)
+	d.offset += int64(n)
+	return n, err
+}
+
+// next gets the next length bytes of input and advances past them.
+// The returned slice is only valid until the next call on this decoder.
+func (d *decoder) next(length int) ([]byte, error) {
+	if d.limit > 0 && d.offset+int64(length) > d.limit {
+		return nil, ErrLimitExceeded
+	}
+
+	data, err := d.reader.Peek(length)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	d.reader.Discard(length)
+	d.offset += int64(length)
+	return data, nil
+}
+
+// atEOF determines whether or not the input has been read to its end. Input cut off by the limit is not at its end.
+func (d *decoder) atEOF() bool {
+	if d.limit > 0 && d.offset >= d.limit {
+		return false
+	}
+
+	_, err := d.reader.Peek(1)
+	return err == io.EOF
+}
+
+func (d *decoder) uint8() (byte, error) {
+	data, err := d.next(1)
+	if err != nil {
+		return 0, err
+	}
+
+	return data[0], nil
+}
+
+func (d *decoder) uint16() (uint16, error) {
+	data, err := d.next(2)
+	if err != nil {
+		return 0, err
+	}
+
+	return binary.LittleEndian.Uint16(data), nil
+}
+
+func (d *decoder) uint32() (uint32, error) {
+	data, err := d.next(4)
+	if err != nil {
+		return 0, err
+	}
+
+	return binary.LittleEndian.Uint32(data), nil
+}
+
+func (d *decoder) uint64() (uint64, error) {
+	data, err := d.next(8)
+	if err != nil {
+		return 0, err
+	}
+
+	return binary.LittleEndian.Uint64(data), nil
+}
+
+func (d *decoder) float32() (float32, error) {
+	data, err := d.next(4)
+	if err != nil {
+		return 0, err
+	}
+
+	return math.Float32frombits(binary.LittleEndian.Uint32(data)), nil
+}
+
+func (d *decoder) vector3() (Vector3, error) {
+	data, err := d.next(12)
+	if err != nil {
+		return Vector3{}, err
+	}
+
+	return Vector3{
+		X: math.Float32frombits(binary.LittleEndian.Uint32(data[0:])),
+		Y: math.Float32frombits(binary.LittleEndian.Uint32(data[4:])),
+		Z: math.Float32frombits(binary.LittleEndian.Uint32(data[8:]))}, nil
+}
+
+func (d *decoder) string(length int) (string, error) {
+	data, err := d.next(length)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
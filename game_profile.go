@@ -0,0 +1,285 @@
+/*
+	gonav - A Source Engine navigation mesh file parser written in Go.
+	Copyright (C) 2016  Matt Razza
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as published
+	by the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package gonav provides functionality related to CS:GO Nav Meshes
+package gonav
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+// CustomDataDecoder decodes the game-specific data a Source title appends to the nav format.
+// Decoders store what they read on the supplied NavMesh or NavArea.
+type CustomDataDecoder interface {
+	// DecodeMeshData is called once the header and places are read, before any areas.
+	DecodeMeshData(r io.Reader, mesh *NavMesh) error
+
+	// DecodeAreaData is called at the end of each area, once its common fields are read.
+	DecodeAreaData(r io.Reader, mesh *NavMesh, area *NavArea) error
+}
+
+// CustomDataEncoder encodes the game-specific data read by a CustomDataDecoder.
+// The mesh passed to it carries the major and minor version being written.
+type CustomDataEncoder interface {
+	// EncodeMeshData is called once the header and places are written, before any areas.
+	EncodeMeshData(w io.Writer, mesh *NavMesh) error
+
+	// EncodeAreaData is called at the end of each area, once its common fields are written.
+	EncodeAreaData(w io.Writer, mesh *NavMesh, area *NavArea) error
+}
+
+// GameProfile describes how a specific Source title extends the nav format.
+type GameProfile interface {
+	CustomDataDecoder
+	CustomDataEncoder
+}
+
+// CSGOProfile is the GameProfile for Counter-Strike: Global Offensive.
+// CS:GO stores its area binds at the end of each area; see NavArea.AreaBinds.
+type CSGOProfile struct{}
+
+// DecodeMeshData decodes the mesh-level custom data; CS:GO has none.
+func (CSGOProfile) DecodeMeshData(r io.Reader, mesh *NavMesh) error {
+	return nil
+}
+
+// DecodeAreaData decodes the area binds for the specified area.
+func (CSGOProfile) DecodeAreaData(r io.Reader, mesh *NavMesh, area *NavArea) error {
+	var bindCount byte
+	if err := binary.Read(r, binary.LittleEndian, &bindCount); err != nil {
+		return err
+	}
+
+	for bindIndex := byte(0); bindIndex < bindCount; bindIndex++ {
+		var currBind NavAreaBind
+
+		if err := binary.Read(r, binary.LittleEndian, &currBind.TargetAreaID); err != nil {
+			return err
+		}
+
+		if err := binary.Read(r, binary.LittleEndian, &currBind.Data); err != nil {
+			return err
+		}
+
+		area.AreaBinds = append(area.AreaBinds, &currBind)
+	}
+
+	return nil
+}
+
+// EncodeMeshData encodes the mesh-level custom data; CS:GO has none.
+func (CSGOProfile) EncodeMeshData(w io.Writer, mesh *NavMesh) error {
+	return nil
+}
+
+// EncodeAreaData encodes the area binds for the specified area.
+func (CSGOProfile) EncodeAreaData(w io.Writer, mesh *NavMesh, area *NavArea) error {
+	if len(area.AreaBinds) > math.MaxUint8 {
+		return errors.New("Too many area binds to encode.")
+	}
+
+	if err := binary.Write(w, binary.LittleEndian, byte(len(area.AreaBinds))); err != nil {
+		return err
+	}
+
+	for _, currBind := range area.AreaBinds {
+		if err := binary.Write(w, binary.LittleEndian, currBind.TargetAreaID); err != nil {
+			return err
+		}
+
+		if err := binary.Write(w, binary.LittleEndian, currBind.Data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// TF2AreaAttribute represents the Team Fortress 2 specific attribute bits of a NavArea
+type TF2AreaAttribute uint32
+
+const (
+	TF2AreaBlocked             TF2AreaAttribute = 0x00000001 // The area is blocked
+	TF2AreaSpawnRoomRed        TF2AreaAttribute = 0x00000002 // The area is in a red spawn room
+	TF2AreaSpawnRoomBlue       TF2AreaAttribute = 0x00000004 // The area is in a blue spawn room
+	TF2AreaSpawnRoomExit       TF2AreaAttribute = 0x00000008 // The area is a spawn room exit
+	TF2AreaHasAmmo             TF2AreaAttribute = 0x00000010 // The area has ammo
+	TF2AreaHasHealth           TF2AreaAttribute = 0x00000020 // The area has health
+	TF2AreaControlPoint        TF2AreaAttribute = 0x00000040 // The area is on a control point
+	TF2AreaBlueSentryDanger    TF2AreaAttribute = 0x00000080 // The area is covered by a blue sentry
+	TF2AreaRedSentryDanger     TF2AreaAttribute = 0x00000100 // The area is covered by a red sentry
+	TF2AreaBlueSetupGate       TF2AreaAttribute = 0x00000800 // The area is a blue setup gate
+	TF2AreaRedSetupGate        TF2AreaAttribute = 0x00001000 // The area is a red setup gate
+	TF2AreaBlockedAfterCapture TF2AreaAttribute = 0x00002000 // The area is blocked once the point is captured
+	TF2AreaBlockedUntilCapture TF2AreaAttribute = 0x00004000 // The area is blocked until the point is captured
+	TF2AreaBlueOneWayDoor      TF2AreaAttribute = 0x00008000 // The area is a blue one-way door
+	TF2AreaRedOneWayDoor       TF2AreaAttribute = 0x00010000 // The area is a red one-way door
+	TF2AreaSniperSpot          TF2AreaAttribute = 0x00200000 // The area is a sniper spot
+	TF2AreaSentrySpot          TF2AreaAttribute = 0x00400000 // The area is a sentry spot
+	TF2AreaNoSpawning          TF2AreaAttribute = 0x02000000 // Nothing may spawn in the area
+	TF2AreaRescueCloset        TF2AreaAttribute = 0x04000000 // The area is a rescue closet
+	TF2AreaBombCanDropHere     TF2AreaAttribute = 0x08000000 // The bomb may be dropped in the area
+	TF2AreaDoorNeverBlocks     TF2AreaAttribute = 0x10000000 // Doors never block the area
+	TF2AreaDoorAlwaysBlocks    TF2AreaAttribute = 0x20000000 // Doors always block the area
+	TF2AreaUnblockable         TF2AreaAttribute = 0x40000000 // The area can never be blocked
+)
+
+// TF2AreaData is the Team Fortress 2 specific data of a NavArea
+type TF2AreaData struct {
+	Attributes TF2AreaAttribute // The TF2 attribute bits of the area
+}
+
+// TF2Profile is the GameProfile for Team Fortress 2.
+// TF2 appends a uint32 of attribute bits to each area of meshes with a game sub-version; see NavArea.TF2Data.
+type TF2Profile struct{}
+
+// DecodeMeshData decodes the mesh-level custom data; TF2 has none.
+func (TF2Profile) DecodeMeshData(r io.Reader, mesh *NavMesh) error {
+	return nil
+}
+
+// DecodeAreaData decodes the TF2 attribute bits of the specified area.
+func (TF2Profile) DecodeAreaData(r io.Reader, mesh *NavMesh, area *NavArea) error {
+	var data TF2AreaData
+	area.CustomData = &data
+
+	if !hasGameSubVersion(mesh) {
+		return nil
+	}
+
+	return binary.Read(r, binary.LittleEndian, &data.Attributes)
+}
+
+// EncodeMeshData encodes the mesh-level custom data; TF2 has none.
+func (TF2Profile) EncodeMeshData(w io.Writer, mesh *NavMesh) error {
+	return nil
+}
+
+// EncodeAreaData encodes the TF2 attribute bits of the specified area.
+func (TF2Profile) EncodeAreaData(w io.Writer, mesh *NavMesh, area *NavArea) error {
+	if !hasGameSubVersion(mesh) {
+		return nil
+	}
+
+	var attributes TF2AreaAttribute
+	if data := area.TF2Data(); data != nil {
+		attributes = data.Attributes
+	}
+
+	return binary.Write(w, binary.LittleEndian, attributes)
+}
+
+// L4D2SpawnAttribute represents the Left 4 Dead 2 specific spawn attribute bits of a NavArea
+type L4D2SpawnAttribute uint32
+
+const (
+	L4D2SpawnEmpty            L4D2SpawnAttribute = 0x00000002 // The area is empty
+	L4D2SpawnStopScan         L4D2SpawnAttribute = 0x00000004 // Flow scans stop at the area
+	L4D2SpawnBattlestation    L4D2SpawnAttribute = 0x00000020 // The area is a battlestation
+	L4D2SpawnFinale           L4D2SpawnAttribute = 0x00000040 // The area is part of the finale
+	L4D2SpawnPlayerStart      L4D2SpawnAttribute = 0x00000080 // Survivors start in the area
+	L4D2SpawnBattlefield      L4D2SpawnAttribute = 0x00000100 // The area is a battlefield
+	L4D2SpawnIgnoreVisibility L4D2SpawnAttribute = 0x00000200 // Visibility is ignored when spawning in the area
+	L4D2SpawnNotClearable     L4D2SpawnAttribute = 0x00000400 // The area cannot be cleared
+	L4D2SpawnCheckpoint       L4D2SpawnAttribute = 0x00000800 // The area is in a checkpoint
+	L4D2SpawnObscured         L4D2SpawnAttribute = 0x00001000 // The area is obscured
+	L4D2SpawnNoMobs           L4D2SpawnAttribute = 0x00002000 // Mobs may not spawn in the area
+	L4D2SpawnThreat           L4D2SpawnAttribute = 0x00004000 // A threat may spawn in the area
+	L4D2SpawnRescueVehicle    L4D2SpawnAttribute = 0x00008000 // The rescue vehicle arrives in the area
+	L4D2SpawnRescueCloset     L4D2SpawnAttribute = 0x00010000 // The area is a rescue closet
+	L4D2SpawnEscapeRoute      L4D2SpawnAttribute = 0x00020000 // The area is on the escape route
+	L4D2SpawnDestroyedDoor    L4D2SpawnAttribute = 0x00040000 // The area contains a destroyed door
+	L4D2SpawnNoThreat         L4D2SpawnAttribute = 0x00080000 // Threats may not spawn in the area
+	L4D2SpawnLyingDown        L4D2SpawnAttribute = 0x00100000 // Survivors in the area are lying down
+)
+
+// L4D2AreaData is the Left 4 Dead 2 specific data of a NavArea
+type L4D2AreaData struct {
+	SpawnAttributes L4D2SpawnAttribute // The L4D2 spawn attribute bits of the area
+}
+
+// L4D2Profile is the GameProfile for Left 4 Dead 2.
+// L4D2 appends a uint32 of spawn attribute bits to each area of meshes with a game sub-version; see NavArea.L4D2Data.
+type L4D2Profile struct{}
+
+// DecodeMeshData decodes the mesh-level custom data; L4D2 has none.
+func (L4D2Profile) DecodeMeshData(r io.Reader, mesh *NavMesh) error {
+	return nil
+}
+
+// DecodeAreaData decodes the L4D2 spawn attribute bits of the specified area.
+func (L4D2Profile) DecodeAreaData(r io.Reader, mesh *NavMesh, area *NavArea) error {
+	var data L4D2AreaData
+	area.CustomData = &data
+
+	if !hasGameSubVersion(mesh) {
+		return nil
+	}
+
+	return binary.Read(r, binary.LittleEndian, &data.SpawnAttributes)
+}
+
+// EncodeMeshData encodes the mesh-level custom data; L4D2 has none.
+func (L4D2Profile) EncodeMeshData(w io.Writer, mesh *NavMesh) error {
+	return nil
+}
+
+// EncodeAreaData encodes the L4D2 spawn attribute bits of the specified area.
+func (L4D2Profile) EncodeAreaData(w io.Writer, mesh *NavMesh, area *NavArea) error {
+	if !hasGameSubVersion(mesh) {
+		return nil
+	}
+
+	var attributes L4D2SpawnAttribute
+	if data := area.L4D2Data(); data != nil {
+		attributes = data.SpawnAttributes
+	}
+
+	return binary.Write(w, binary.LittleEndian, attributes)
+}
+
+// TF2Data gets the TF2 specific data of this area; nil if the mesh was not parsed with the TF2Profile
+func (area *NavArea) TF2Data() *TF2AreaData {
+	data, _ := area.CustomData.(*TF2AreaData)
+	return data
+}
+
+// L4D2Data gets the L4D2 specific data of this area; nil if the mesh was not parsed with the L4D2Profile
+func (area *NavArea) L4D2Data() *L4D2AreaData {
+	data, _ := area.CustomData.(*L4D2AreaData)
+	return data
+}
+
+// hasGameSubVersion determines whether or not the specified mesh was saved with a game-specific sub-version.
+// The engine's own sub-version is 0, and games only append their area data once they have bumped it; meshes
+// before major version 10 have no sub-version at all.
+func hasGameSubVersion(mesh *NavMesh) bool {
+	return mesh.MajorVersion >= 10 && mesh.MinorVersion > 0
+}
+
+// profileOrDefault gets the specified profile or the CS:GO profile if none is specified
+func profileOrDefault(profile GameProfile) GameProfile {
+	if profile == nil {
+		return CSGOProfile{}
+	}
+
+	return profile
+}
@@ -0,0 +1,84 @@
+/*
+	gonav - A Source Engine navigation mesh file parser written in Go.
+	Copyright (C) 2016  Matt Razza
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as published
+	by the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package gonav provides functionality related to CS:GO Nav Meshes
+package gonav
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGameProfileRoundTrip(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		profile  GameProfile
+		setData  func(area *NavArea)
+		getData  func(area *NavArea) uint32
+		expected uint32
+		gated    bool // Whether the data is only stored with a game sub-version
+	}{
+		{"TF2", TF2Profile{},
+			func(area *NavArea) { area.CustomData = &TF2AreaData{Attributes: TF2AreaSniperSpot | TF2AreaHasAmmo} },
+			func(area *NavArea) uint32 { return uint32(area.TF2Data().Attributes) },
+			uint32(TF2AreaSniperSpot | TF2AreaHasAmmo), true},
+		{"L4D2", L4D2Profile{},
+			func(area *NavArea) { area.CustomData = &L4D2AreaData{SpawnAttributes: L4D2SpawnCheckpoint} },
+			func(area *NavArea) uint32 { return uint32(area.L4D2Data().SpawnAttributes) },
+			uint32(L4D2SpawnCheckpoint), true},
+		{"CS:GO", CSGOProfile{},
+			func(area *NavArea) { area.AreaBinds = []*NavAreaBind{{TargetAreaID: 2, Data: [10]byte{1, 2, 3}}} },
+			func(area *NavArea) uint32 { return uint32(len(area.AreaBinds)) },
+			1, false},
+	} {
+		for _, version := range []struct {
+			major, minor  uint32
+			hasSubVersion bool
+		}{{16, 2, true}, {16, 0, false}, {9, 2, false}} {
+			source := newTestMesh(2)
+			source.Profile = test.profile
+			source.MinorVersion = version.minor
+
+			for _, currArea := range source.Areas {
+				test.setData(currArea)
+			}
+
+			data := writeTestMesh(t, source, version.major)
+			parser := Parser{Reader: bytes.NewReader(data), Profile: test.profile}
+			mesh, err := parser.Parse()
+			if err != nil {
+				t.Fatalf("%v %v.%v: %v", test.name, version.major, version.minor, err)
+			}
+
+			expected := test.expected
+			if test.gated && !version.hasSubVersion {
+				expected = 0
+			}
+
+			for _, currArea := range mesh.Areas {
+				if actual := test.getData(currArea); actual != expected {
+					t.Errorf("%v %v.%v: area %v has data %v, expected %v.", test.name, version.major, version.minor, currArea.ID, actual, expected)
+				}
+			}
+
+			if written := writeTestMesh(t, &mesh, version.major); !bytes.Equal(written, data) {
+				t.Errorf("%v %v.%v: the parsed mesh was not written back byte for byte.", test.name, version.major, version.minor)
+			}
+		}
+	}
+}
@@ -0,0 +1,169 @@
+/*
+	gonav - A Source Engine navigation mesh file parser written in Go.
+	Copyright (C) 2016  Matt Razza
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as published
+	by the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package gonav provides functionality related to CS:GO Nav Meshes
+package gonav
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/bzip2"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	bspPakfileLump  int   = 40   // Index of the lump holding the pakfile zip
+	bspHeaderLength int64 = 1036 // Length of the BSP header (ident, version, lumps and map revision)
+)
+
+var (
+	navMagic   = []byte{0xCE, 0xFA, 0xED, 0xFE}
+	bzip2Magic = []byte("BZh")
+	bspMagic   = []byte("VBSP")
+)
+
+// ParseFile parses the nav mesh in the specified file. The file may be a plain .nav, a bzip2
+// compressed .nav.bz2 or a .bsp with the nav packed into its pakfile; the format is picked
+// from the magic bytes. The Reader of this Parser is replaced.
+// Every error, including failing to open the file, is returned as a *ParseError.
+func (p *Parser) ParseFile(path string) (NavMesh, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return NavMesh{}, loadError("Failed to open the file", err)
+	}
+
+	defer f.Close()
+
+	var magic [4]byte
+	n, err := io.ReadFull(f, magic[:])
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return NavMesh{}, loadError("Failed to read the magic number", unexpectedEOF(err))
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return NavMesh{}, loadError("Failed to rewind the file", err)
+	}
+
+	switch {
+	case bytes.HasPrefix(magic[:n], bspMagic):
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		return p.parseFromBSP(f, name)
+
+	case bytes.HasPrefix(magic[:n], bzip2Magic):
+		return p.ParseBzip2(f)
+
+	case bytes.HasPrefix(magic[:n], navMagic):
+		p.Reader = f
+		return p.Parse()
+	}
+
+	return NavMesh{}, loadError(fmt.Sprintf("File %v is not a .nav, .nav.bz2 or .bsp file", path), ErrBadMagic)
+}
+
+// ParseBzip2 parses a bzip2 compressed nav mesh, such as the .nav.bz2 files served for fast downloads.
+// The Reader of this Parser is replaced.
+func (p *Parser) ParseBzip2(r io.Reader) (NavMesh, error) {
+	p.Reader = bzip2.NewReader(r)
+	return p.Parse()
+}
+
+// ParseFromBSP parses the nav mesh packed into the pakfile lump of a BSP.
+// The first maps/*.nav entry in the pakfile is used. The Reader of this Parser is replaced.
+// Errors reading the BSP are returned as a *ParseError, just like errors parsing the nav mesh.
+func (p *Parser) ParseFromBSP(r io.ReaderAt) (NavMesh, error) {
+	return p.parseFromBSP(r, "")
+}
+
+// parseFromBSP parses the nav mesh packed into the pakfile lump of a BSP, preferring
+// maps/<name>.nav if a name is specified.
+func (p *Parser) parseFromBSP(r io.ReaderAt, name string) (NavMesh, error) {
+	pakfile, err := openBSPPakfile(r)
+	if err != nil {
+		return NavMesh{}, err
+	}
+
+	var navFile *zip.File
+	for _, currFile := range pakfile.File {
+		currName := strings.ToLower(strings.Replace(currFile.Name, "\\", "/", -1))
+
+		if currName == strings.ToLower("maps/"+name+".nav") {
+			navFile = currFile
+			break
+		} else if navFile == nil && strings.HasPrefix(currName, "maps/") && strings.HasSuffix(currName, ".nav") {
+			navFile = currFile
+		}
+	}
+
+	if navFile == nil {
+		return NavMesh{}, loadError("No maps/*.nav file in the BSP pakfile", ErrNoNavFile)
+	}
+
+	rc, err := navFile.Open()
+	if err != nil {
+		return NavMesh{}, loadError(fmt.Sprintf("Failed to open %v in the BSP pakfile", navFile.Name), err)
+	}
+
+	defer rc.Close()
+
+	p.Reader = rc
+	return p.Parse()
+}
+
+// openBSPPakfile opens the zip stored in the pakfile lump of a BSP
+func openBSPPakfile(r io.ReaderAt) (*zip.Reader, error) {
+	header := make([]byte, bspHeaderLength)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, loadError("Failed to read the BSP header", unexpectedEOF(err))
+	}
+
+	if !bytes.Equal(header[:4], bspMagic) {
+		return nil, loadError("Magic number is incorrect. This is not a .bsp file", ErrBadMagic)
+	}
+
+	// Lumps are {offset, length, version, fourCC}, except in Left 4 Dead 2 which
+	// uses {version, offset, length, fourCC}. A real offset is never inside the header.
+	lump := header[8+bspPakfileLump*16:]
+	offset := int64(int32(binary.LittleEndian.Uint32(lump[0:])))
+	length := int64(int32(binary.LittleEndian.Uint32(lump[4:])))
+
+	if offset < bspHeaderLength {
+		offset = int64(int32(binary.LittleEndian.Uint32(lump[4:])))
+		length = int64(int32(binary.LittleEndian.Uint32(lump[8:])))
+	}
+
+	if offset < bspHeaderLength || length <= 0 {
+		return nil, loadError("The BSP does not have a pakfile", ErrNoNavFile)
+	}
+
+	pakfile, err := zip.NewReader(io.NewSectionReader(r, offset, length), length)
+	if err != nil {
+		return nil, loadError("Failed to read the BSP pakfile", err)
+	}
+
+	return pakfile, nil
+}
+
+// loadError builds the *ParseError for a failure to find the nav mesh in a file before parsing it
+func loadError(message string, err error) *ParseError {
+	return &ParseError{Section: ParseSectionHeader, AreaIndex: -1, Message: message, Err: err}
+}
@@ -0,0 +1,154 @@
+/*
+	gonav - A Source Engine navigation mesh file parser written in Go.
+	Copyright (C) 2016  Matt Razza
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as published
+	by the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package gonav provides functionality related to CS:GO Nav Meshes
+package gonav
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/bzip2"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// bzip2TestMesh is an empty version 16.1 mesh with a BSPSize of 7, compressed with bzip2
+var bzip2TestMesh = []byte{
+	0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59, 0xf7, 0x30, 0xfc, 0x37, 0x00, 0x00,
+	0x07, 0x60, 0x05, 0xe0, 0x88, 0x40, 0x00, 0x00, 0x01, 0x00, 0x02, 0x00, 0x11, 0x20, 0x00, 0x21,
+	0xa1, 0xa0, 0xf5, 0x08, 0x32, 0x62, 0x2a, 0xdb, 0x50, 0x8c, 0x38, 0xf1, 0x77, 0x24, 0x53, 0x85,
+	0x09, 0x0f, 0x73, 0x0f, 0xc3, 0x70}
+
+// loaderTestMesh writes the mesh held in bzip2TestMesh uncompressed
+func loaderTestMesh(tb testing.TB) []byte {
+	return writeTestMesh(tb, &NavMesh{MajorVersion: 16, MinorVersion: 1, BSPSize: 7}, 16)
+}
+
+// bspTestFile builds a BSP whose pakfile holds the specified files; no pakfile if files is nil.
+// Left 4 Dead 2 BSPs store the lump version before its offset and length.
+func bspTestFile(tb testing.TB, files map[string][]byte, l4d2 bool) []byte {
+	header := make([]byte, bspHeaderLength)
+	copy(header, bspMagic)
+	binary.LittleEndian.PutUint32(header[4:], 21)
+
+	if files == nil {
+		return header
+	}
+
+	var pakfile bytes.Buffer
+	zipWriter := zip.NewWriter(&pakfile)
+
+	for name, data := range files {
+		w, err := zipWriter.Create(name)
+		if err != nil {
+			tb.Fatal(err)
+		}
+
+		w.Write(data)
+	}
+
+	if err := zipWriter.Close(); err != nil {
+		tb.Fatal(err)
+	}
+
+	lump := header[8+bspPakfileLump*16:]
+	if l4d2 {
+		lump = lump[4:]
+	}
+
+	binary.LittleEndian.PutUint32(lump[0:], uint32(bspHeaderLength))
+	binary.LittleEndian.PutUint32(lump[4:], uint32(pakfile.Len()))
+
+	return append(header, pakfile.Bytes()...)
+}
+
+func TestParseFile(t *testing.T) {
+	nav := loaderTestMesh(t)
+
+	if decompressed, err := ioutil.ReadAll(bzip2.NewReader(bytes.NewReader(bzip2TestMesh))); err != nil || !bytes.Equal(decompressed, nav) {
+		t.Fatalf("The bzip2 test mesh does not hold the loader test mesh (%v).", err)
+	}
+
+	for _, test := range []struct {
+		name string
+		data []byte
+		err  error // The error the parse must wrap; nil if it must succeed
+	}{
+		{"de_test.nav", nav, nil},
+		{"de_test.nav.bz2", bzip2TestMesh, nil},
+		{"de_test.bsp", bspTestFile(t, map[string][]byte{"maps/other.nav": nil, "maps/de_test.nav": nav}, false), nil},
+		{"l4d2.bsp", bspTestFile(t, map[string][]byte{"maps/l4d2.nav": nav}, true), nil},
+		{"no_pakfile.bsp", bspTestFile(t, nil, false), ErrNoNavFile},
+		{"no_nav.bsp", bspTestFile(t, map[string][]byte{"materials/wall.vmt": nil}, false), ErrNoNavFile},
+		{"empty.nav", nil, io.ErrUnexpectedEOF},
+		{"truncated.nav", nav[:10], io.ErrUnexpectedEOF},
+		{"truncated.bsp", bspTestFile(t, nil, false)[:100], io.ErrUnexpectedEOF},
+		{"unknown.nav", []byte("hello"), ErrBadMagic},
+	} {
+		path := filepath.Join(t.TempDir(), test.name)
+		if err := ioutil.WriteFile(path, test.data, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		var parser Parser
+		mesh, err := parser.ParseFile(path)
+
+		if test.err == nil {
+			if err != nil {
+				t.Errorf("%v: %v", test.name, err)
+			} else if mesh.BSPSize != 7 {
+				t.Errorf("%v: parsed a mesh with a BSPSize of %v, expected 7.", test.name, mesh.BSPSize)
+			}
+
+			continue
+		}
+
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) || !errors.Is(err, test.err) {
+			t.Errorf("%v: expected a *ParseError wrapping %v, got %v.", test.name, test.err, err)
+		}
+	}
+
+	var parser Parser
+	if _, err := parser.ParseFile(filepath.Join(t.TempDir(), "missing.nav")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected a missing file to fail with os.ErrNotExist, got %v.", err)
+	}
+}
+
+func TestParseBzip2AndBSPReaders(t *testing.T) {
+	var parser Parser
+
+	if mesh, err := parser.ParseBzip2(bytes.NewReader(bzip2TestMesh)); err != nil || mesh.BSPSize != 7 {
+		t.Errorf("Failed to parse the bzip2 test mesh: %v", err)
+	}
+
+	var parseErr *ParseError
+	if _, err := parser.ParseBzip2(bytes.NewReader(bzip2TestMesh[:30])); !errors.As(err, &parseErr) {
+		t.Errorf("Expected a truncated bzip2 stream to fail with a *ParseError, got %v.", err)
+	}
+
+	bsp := bspTestFile(t, map[string][]byte{"maps/de_test.nav": loaderTestMesh(t)}, false)
+	if mesh, err := parser.ParseFromBSP(bytes.NewReader(bsp)); err != nil || mesh.BSPSize != 7 {
+		t.Errorf("Failed to parse the BSP test mesh: %v", err)
+	}
+}
@@ -0,0 +1,39 @@
+/*
+	gonav - A Source Engine navigation mesh file parser written in Go.
+	Copyright (C) 2016  Matt Razza
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as published
+	by the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package gonav provides functionality related to CS:GO Nav Meshes
+package gonav
+
+// NavApproachArea represents a way of approaching a NavArea. Only meshes with a
+// MajorVersion below 15 contain approach data.
+type NavApproachArea struct {
+	HereAreaID    uint32   // ID of the area being approached
+	HereArea      *NavArea // The area being approached
+	PrevAreaID    uint32   // ID of the area the approach comes from
+	PrevArea      *NavArea // The area the approach comes from
+	PrevToHereHow byte     // How the approach gets from the previous area to here
+	NextAreaID    uint32   // ID of the area the approach continues to
+	NextArea      *NavArea // The area the approach continues to
+	HereToNextHow byte     // How the approach gets from here to the next area
+}
+
+func (approach *NavApproachArea) connectGraph(mesh *NavMesh) {
+	approach.HereArea = mesh.Areas[approach.HereAreaID]
+	approach.PrevArea = mesh.Areas[approach.PrevAreaID]
+	approach.NextArea = mesh.Areas[approach.NextAreaID]
+}
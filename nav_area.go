@@ -27,8 +27,9 @@ import (
 // NavArea represents a NavArea as part of a NavMesh
 type NavArea struct {
 	ID                           uint32                 // ID of the NavArea
-	NorthWest                    Vector3                // Location of the north-west point of this NavArea
-	SouthEast                    Vector3                // Location of the south-east point of this NavArea
+	NorthWest                    Vector3                // Location of the north-west point of this NavArea (bounding box of polygons)
+	SouthEast                    Vector3                // Location of the south-east point of this NavArea (bounding box of polygons)
+	Corners                      []Vector3              // The corners of this NavArea if it is a polygon (CS2); nil for rectangles
 	Flags                        uint32                 // Bitflags set on this area
 	NorthEastZ                   float32                // The Z-coord for the north-east point
 	SouthWestZ                   float32                // The Z-coord for the south-west point
@@ -41,46 +42,292 @@ type NavArea struct {
 	HidingSpots                  []*NavHidingSpot       // The hiding spots in this NavArea
 	EncounterPaths               []*NavEncounterPath    // The encounter paths for this area
 	LadderConnections            []*NavLadderConnection // Connections between this area and ladders
+	ApproachAreas                []*NavApproachArea     // Ways of approaching this area (MajorVersion < 15)
 	VisibleAreas                 []*NavVisibleArea      // Visible areas
+	AreaBinds                    []*NavAreaBind         // Area-bind records trailing this area (CS:GO)
 	EarliestOccupyTimeFirstTeam  float32                // The earliest time the first team can occupy this area
 	EarliestOccupyTimeSecondTeam float32                // The earliest time the second team can occupy this area
 	InheritVisibilityFromAreaID  uint32                 // ID of the area to inherit our visibility from
+	CustomData                   interface{}            // Game-specific data, set by the GameProfile
+
+	incoming []*NavConnection // Connections from other areas to this area
 }
 
 // NavHidingSpot represents an identified hiding spot within a NavArea
 type NavHidingSpot struct {
-	ID       uint32  // ID of the hiding spot
-	Location Vector3 // Location of the hiding NavHidingSpot
-	Flags    byte    // Bitflags associated with this hiding spot
+	ID       uint32             // ID of the hiding spot
+	Location Vector3            // Location of the hiding NavHidingSpot
+	Flags    NavHidingSpotFlags // Bitflags associated with this hiding spot
+	Area     *NavArea           // The area this hiding spot is in
 }
 
 // NavVisibleArea represents a visible area
 type NavVisibleArea struct {
-	VisibleAreaID uint32   // ID of the visible area
-	VisibleArea   *NavArea // The visible area
-	Attributes    byte     // Bit-wise attributes
+	VisibleAreaID uint32             // ID of the visible area
+	VisibleArea   *NavArea           // The visible area
+	Attributes    NavVisibilityFlags // How much of the area is visible; NavVisibilityNotVisible hides an inherited area
+}
+
+// NavAreaBind represents one of the 14-byte area-bind records CS:GO appends to each area (see CSGOProfile).
+// The records are laid out on disk as follows:
+//
+//	uint32   TargetAreaID
+//	byte[10] Data
+//
+// Only the leading area ID is understood; the rest is kept verbatim so it can be written back out.
+type NavAreaBind struct {
+	TargetAreaID uint32   // ID of the bound area
+	TargetArea   *NavArea // The bound area
+	Data         [10]byte // The remainder of the record
 }
 
 func (visArea *NavVisibleArea) connectGraph(mesh *NavMesh) {
 	visArea.VisibleArea = mesh.Areas[visArea.VisibleAreaID]
 }
 
+func (bind *NavAreaBind) connectGraph(mesh *NavMesh) {
+	bind.TargetArea = mesh.Areas[bind.TargetAreaID]
+}
+
 func (area *NavArea) connectGraph(mesh *NavMesh) {
+	for _, currSpot := range area.HidingSpots {
+		currSpot.Area = area
+	}
+
 	for _, currConnection := range area.Connections {
 		currConnection.connectGraph(mesh)
 	}
 
+	spots := mesh.hidingSpotIndex()
+
 	for _, currPath := range area.EncounterPaths {
-		currPath.connectGraph(mesh)
+		currPath.connectGraph(mesh, area, spots)
 	}
 
 	for _, currLadder := range area.LadderConnections {
 		currLadder.connectGraph(mesh)
 	}
 
+	for _, currApproach := range area.ApproachAreas {
+		currApproach.connectGraph(mesh)
+	}
+
 	for _, currArea := range area.VisibleAreas {
 		currArea.connectGraph(mesh)
 	}
+
+	for _, currBind := range area.AreaBinds {
+		currBind.connectGraph(mesh)
+	}
+}
+
+// referencesArea determines whether or not this area holds any reference to the area with the specified ID
+func (area *NavArea) referencesArea(id uint32) bool {
+	return area.referencesAnyArea(func(currID uint32) bool { return currID == id })
+}
+
+// referencesAnyArea determines whether or not this area references any area whose ID is matched
+func (area *NavArea) referencesAnyArea(matches func(uint32) bool) bool {
+	if area.InheritVisibilityFromAreaID != 0 && matches(area.InheritVisibilityFromAreaID) {
+		return true
+	}
+
+	for _, currConnection := range area.Connections {
+		if matches(currConnection.TargetAreaID) {
+			return true
+		}
+	}
+
+	for _, currPath := range area.EncounterPaths {
+		if matches(currPath.FromAreaID) || matches(currPath.ToAreaID) {
+			return true
+		}
+	}
+
+	for _, currApproach := range area.ApproachAreas {
+		if matches(currApproach.HereAreaID) || matches(currApproach.PrevAreaID) || matches(currApproach.NextAreaID) {
+			return true
+		}
+	}
+
+	for _, currArea := range area.VisibleAreas {
+		if matches(currArea.VisibleAreaID) {
+			return true
+		}
+	}
+
+	for _, currBind := range area.AreaBinds {
+		if matches(currBind.TargetAreaID) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// removeConnectionsTo removes every connection from this area to the area with the specified ID
+func (area *NavArea) removeConnectionsTo(id uint32) {
+	connections := area.Connections[:0]
+
+	for _, currConnection := range area.Connections {
+		if currConnection.TargetAreaID != id {
+			connections = append(connections, currConnection)
+		}
+	}
+
+	area.Connections = connections
+}
+
+// removeReferencesToAny removes everything in this area that references an area whose ID is removed
+func (area *NavArea) removeReferencesToAny(removed func(uint32) bool) {
+	connections := area.Connections[:0]
+	for _, currConnection := range area.Connections {
+		if !removed(currConnection.TargetAreaID) {
+			connections = append(connections, currConnection)
+		}
+	}
+	area.Connections = connections
+
+	if removed(area.InheritVisibilityFromAreaID) {
+		area.InheritVisibilityFromAreaID = 0
+	}
+
+	paths := area.EncounterPaths[:0]
+	for _, currPath := range area.EncounterPaths {
+		if !removed(currPath.FromAreaID) && !removed(currPath.ToAreaID) {
+			paths = append(paths, currPath)
+		}
+	}
+	area.EncounterPaths = paths
+
+	approaches := area.ApproachAreas[:0]
+	for _, currApproach := range area.ApproachAreas {
+		if !removed(currApproach.HereAreaID) && !removed(currApproach.PrevAreaID) && !removed(currApproach.NextAreaID) {
+			approaches = append(approaches, currApproach)
+		}
+	}
+	area.ApproachAreas = approaches
+
+	visibleAreas := area.VisibleAreas[:0]
+	for _, currArea := range area.VisibleAreas {
+		if !removed(currArea.VisibleAreaID) {
+			visibleAreas = append(visibleAreas, currArea)
+		}
+	}
+	area.VisibleAreas = visibleAreas
+
+	binds := area.AreaBinds[:0]
+	for _, currBind := range area.AreaBinds {
+		if !removed(currBind.TargetAreaID) {
+			binds = append(binds, currBind)
+		}
+	}
+	area.AreaBinds = binds
+}
+
+// remapReferences points everything in this area that references an area merged into another at the area it was
+// merged into; merged maps the ID of every merged area to the ID of the area that now covers it. References that
+// now point the area at itself are removed, as are connections that duplicate another, and visibility entries for
+// areas merged together are combined.
+func (area *NavArea) remapReferences(merged map[uint32]uint32) {
+	remap := func(id *uint32) bool {
+		survivor, ok := merged[*id]
+		if ok {
+			*id = survivor
+		}
+
+		return ok && survivor == area.ID
+	}
+
+	connections := area.Connections[:0]
+	connected := make(map[uint32]bool, len(area.Connections))
+	for _, currConnection := range area.Connections {
+		if !remap(&currConnection.TargetAreaID) && !connected[currConnection.TargetAreaID] {
+			connected[currConnection.TargetAreaID] = true
+			connections = append(connections, currConnection)
+		}
+	}
+	area.Connections = connections
+
+	if remap(&area.InheritVisibilityFromAreaID) {
+		area.InheritVisibilityFromAreaID = 0
+	}
+
+	// A path from or to this area no longer crosses it
+	paths := area.EncounterPaths[:0]
+	for _, currPath := range area.EncounterPaths {
+		fromSelf := remap(&currPath.FromAreaID)
+		toSelf := remap(&currPath.ToAreaID)
+
+		if !fromSelf && !toSelf {
+			paths = append(paths, currPath)
+		}
+	}
+	area.EncounterPaths = paths
+
+	for _, currApproach := range area.ApproachAreas {
+		remap(&currApproach.HereAreaID)
+		remap(&currApproach.PrevAreaID)
+		remap(&currApproach.NextAreaID)
+	}
+
+	visibleAreas := area.VisibleAreas[:0]
+	visibleIndexes := make(map[uint32]int, len(area.VisibleAreas))
+	for _, currArea := range area.VisibleAreas {
+		if remap(&currArea.VisibleAreaID) {
+			continue
+		}
+
+		if i, ok := visibleIndexes[currArea.VisibleAreaID]; ok {
+			visibleAreas[i].Attributes = mergeVisibility(visibleAreas[i].Attributes, currArea.Attributes)
+			continue
+		}
+
+		visibleIndexes[currArea.VisibleAreaID] = len(visibleAreas)
+		visibleAreas = append(visibleAreas, currArea)
+	}
+	area.VisibleAreas = visibleAreas
+
+	binds := area.AreaBinds[:0]
+	for _, currBind := range area.AreaBinds {
+		if !remap(&currBind.TargetAreaID) {
+			binds = append(binds, currBind)
+		}
+	}
+	area.AreaBinds = binds
+}
+
+// mergeVisibility gets how visible two areas merged into one are, given how visible each of them was.
+// The merged area is as visible as the more visible of the two, but only partially if just one was completely visible.
+func mergeVisibility(a NavVisibilityFlags, b NavVisibilityFlags) NavVisibilityFlags {
+	if a == b {
+		return a
+	}
+
+	if a < b {
+		a, b = b, a
+	}
+
+	if a&NavVisibilityCompletelyVisible != 0 {
+		return NavVisibilityPartiallyVisible
+	}
+
+	return a
+}
+
+// addLadderConnection connects this area to the specified ladder in the specified direction if it isn't already
+func (area *NavArea) addLadderConnection(ladder *NavLadder, direction NavLadderDirection) {
+	for _, currConnection := range area.LadderConnections {
+		if currConnection.TargetID == ladder.ID && currConnection.Direction == direction {
+			return
+		}
+	}
+
+	area.LadderConnections = append(area.LadderConnections, &NavLadderConnection{
+		SourceArea:   area,
+		TargetID:     ladder.ID,
+		TargetLadder: ladder,
+		Direction:    direction})
 }
 
 // GetNorthEastPoint builds the north east point from the two known corner points and the known Z value
@@ -95,6 +342,10 @@ func (area *NavArea) GetSouthWestPoint() Vector3 {
 
 // GetCenter gets the center point of this area.
 func (area *NavArea) GetCenter() Vector3 {
+	if area.IsPolygon() {
+		return area.polygonCenter()
+	}
+
 	x := (area.NorthWest.X + area.SouthEast.X) / 2.0
 	y := (area.NorthWest.Y + area.GetSouthWestPoint().Y) / 2.0
 	z, err := area.GetZ(x, y)
@@ -116,6 +367,10 @@ func (area *NavArea) GetZ(x, y float32) (float32, error) {
 		return 0, errors.New("Cannot get Z. Specified point does not exist within the area.")
 	}
 
+	if area.IsPolygon() {
+		return area.polygonZ(x, y), nil
+	}
+
 	// Find the Z on the north and south lines that share the X-coord with our point
 	width := area.SouthEast.X - area.NorthWest.X
 	height := area.SouthEast.Y - area.NorthWest.Y
@@ -141,12 +396,22 @@ func (area *NavArea) ContainsPoint(point Vector3, allowBelow bool) bool {
 		return false
 	}
 
+	if area.IsPolygon() {
+		return area.polygonContainsPoint(point.X, point.Y)
+	}
+
 	return area.NorthWest.X <= point.X &&
 		area.NorthWest.Y <= point.Y &&
 		area.SouthEast.X >= point.X &&
 		area.SouthEast.Y >= point.Y
 }
 
+// hasFiniteBounds determines whether or not the north-west and south-east points of this area are finite
+func (area *NavArea) hasFiniteBounds() bool {
+	return isFinite(area.NorthWest.X) && isFinite(area.NorthWest.Y) &&
+		isFinite(area.SouthEast.X) && isFinite(area.SouthEast.Y)
+}
+
 // DistanceFromZ gets the distance the specified point is from a rough estimate of the Z-position
 // for this NavArea
 func (area *NavArea) DistanceFromZ(point Vector3) float32 {
@@ -162,6 +427,10 @@ func (area *NavArea) DistanceFromCenter(point Vector3) float32 {
 
 // GetRoughSquaredArea gets a rough estimate of the squared area of the NavArea
 func (area *NavArea) GetRoughSquaredArea() float32 {
+	if area.IsPolygon() {
+		return area.polygonArea()
+	}
+
 	return (area.SouthEast.X - area.NorthWest.X) * (area.SouthEast.Y - area.NorthWest.Y)
 }
 
@@ -172,6 +441,10 @@ func (area *NavArea) GetClosestPointInArea(point Vector3) Vector3 {
 		return Vector3{point.X, point.Y, z}
 	}
 
+	if area.IsPolygon() {
+		return area.polygonClosestPoint(point.X, point.Y)
+	}
+
 	var x, y float32
 
 	// Let's do X
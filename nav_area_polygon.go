@@ -0,0 +1,231 @@
+/*
+	gonav - A Source Engine navigation mesh file parser written in Go.
+	Copyright (C) 2016  Matt Razza
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as published
+	by the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package gonav provides functionality related to CS:GO Nav Meshes
+package gonav
+
+import "math"
+
+// polygonEdgeTolerance is how far from an edge a point may be and still be considered on it
+const polygonEdgeTolerance float32 = 0.001
+
+// IsPolygon determines whether or not this area is an arbitrary polygon (CS2) rather than a rectangle
+func (area *NavArea) IsPolygon() bool {
+	return len(area.Corners) > 0
+}
+
+// GetEdge gets the start and end points of the specified edge of a polygonal area.
+// Edge i runs from corner i to corner i+1.
+func (area *NavArea) GetEdge(edge int) (Vector3, Vector3) {
+	return area.Corners[edge], area.Corners[(edge+1)%len(area.Corners)]
+}
+
+// setPolygonBounds sets NorthWest and SouthEast to the bounding box of the corners so rough
+// estimates and the quadtree work the same as they do for rectangular areas
+func (area *NavArea) setPolygonBounds() {
+	min := area.Corners[0]
+	max := area.Corners[0]
+	var sumZ float32
+
+	for _, corner := range area.Corners {
+		min.X = float32(math.Min(float64(min.X), float64(corner.X)))
+		min.Y = float32(math.Min(float64(min.Y), float64(corner.Y)))
+		min.Z = float32(math.Min(float64(min.Z), float64(corner.Z)))
+		max.X = float32(math.Max(float64(max.X), float64(corner.X)))
+		max.Y = float32(math.Max(float64(max.Y), float64(corner.Y)))
+		max.Z = float32(math.Max(float64(max.Z), float64(corner.Z)))
+		sumZ += corner.Z
+	}
+
+	area.NorthWest = min
+	area.SouthEast = max
+	area.NorthEastZ = sumZ / float32(len(area.Corners))
+	area.SouthWestZ = area.NorthEastZ
+}
+
+// closestEdge gets the index of the edge of a polygonal area closest to the specified point; 0 for rectangles
+func (area *NavArea) closestEdge(point Vector3) int {
+	bestEdge := 0
+	bestDistance := float32(math.MaxFloat32)
+
+	for edge := 0; edge < len(area.Corners); edge++ {
+		start, end := area.GetEdge(edge)
+
+		if currDistance := distance2D(point.X, point.Y, closestPointOnSegment(point.X, point.Y, start, end)); currDistance < bestDistance {
+			bestEdge = edge
+			bestDistance = currDistance
+		}
+	}
+
+	return bestEdge
+}
+
+// edgeDirection gets the cardinal direction the specified edge faces, relative to the center
+func (area *NavArea) edgeDirection(edge int) NavDirection {
+	start, end := area.GetEdge(edge)
+	center := area.polygonAverage()
+	dx := (start.X+end.X)/2 - center.X
+	dy := (start.Y+end.Y)/2 - center.Y
+
+	if math.Abs(float64(dx)) >= math.Abs(float64(dy)) {
+		if dx > 0 {
+			return NavDirectionEast
+		}
+
+		return NavDirectionWest
+	}
+
+	if dy > 0 {
+		return NavDirectionSouth
+	}
+
+	return NavDirectionNorth
+}
+
+// polygonAverage gets the average of all the corners of this area
+func (area *NavArea) polygonAverage() Vector3 {
+	var sum Vector3
+	for _, corner := range area.Corners {
+		sum.Add(corner)
+	}
+
+	sum.Div(float32(len(area.Corners)))
+	return sum
+}
+
+// polygonCenter gets the center point of this polygonal area
+func (area *NavArea) polygonCenter() Vector3 {
+	center := area.polygonAverage()
+
+	if area.polygonContainsPoint(center.X, center.Y) {
+		center.Z = area.polygonZ(center.X, center.Y)
+	}
+
+	return center
+}
+
+// polygonContainsPoint determines whether or not the specified XY point is within this polygonal area.
+// Points on an edge are contained, the same as for rectangular areas.
+func (area *NavArea) polygonContainsPoint(x, y float32) bool {
+	inside := false
+	corners := area.Corners
+
+	for i, j := 0, len(corners)-1; i < len(corners); j, i = i, i+1 {
+		a, b := corners[i], corners[j]
+
+		if (a.Y > y) != (b.Y > y) && x < (b.X-a.X)*(y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+
+	if inside {
+		return true
+	}
+
+	for edge := range corners {
+		start, end := area.GetEdge(edge)
+		if closest := closestPointOnSegment(x, y, start, end); distance2D(x, y, closest) <= polygonEdgeTolerance {
+			return true
+		}
+	}
+
+	return false
+}
+
+// polygonZ gets the Z-coord for the specified XY point by interpolating across
+// the triangle fan of this polygonal area
+func (area *NavArea) polygonZ(x, y float32) float32 {
+	corners := area.Corners
+	best := float32(math.Inf(-1))
+	bestZ := area.NorthEastZ
+
+	for i := 1; i+1 < len(corners); i++ {
+		a, b, c := corners[0], corners[i], corners[i+1]
+		det := (b.Y-c.Y)*(a.X-c.X) + (c.X-b.X)*(a.Y-c.Y)
+
+		if det == 0 {
+			continue // Degenerate triangle
+		}
+
+		l1 := ((b.Y-c.Y)*(x-c.X) + (c.X-b.X)*(y-c.Y)) / det
+		l2 := ((c.Y-a.Y)*(x-c.X) + (a.X-c.X)*(y-c.Y)) / det
+		l3 := 1 - l1 - l2
+
+		// Use the triangle the point is most inside of
+		if inside := float32(math.Min(float64(l1), math.Min(float64(l2), float64(l3)))); inside > best {
+			best = inside
+			bestZ = l1*a.Z + l2*b.Z + l3*c.Z
+		}
+	}
+
+	return bestZ
+}
+
+// polygonArea gets the area of this polygonal area via the shoelace formula
+func (area *NavArea) polygonArea() float32 {
+	var sum float32
+
+	for edge := range area.Corners {
+		start, end := area.GetEdge(edge)
+		sum += start.X*end.Y - end.X*start.Y
+	}
+
+	return float32(math.Abs(float64(sum))) / 2
+}
+
+// polygonClosestPoint gets the point on the edge of this polygonal area closest to the specified XY point
+func (area *NavArea) polygonClosestPoint(x, y float32) Vector3 {
+	var best Vector3
+	bestDistance := float32(math.MaxFloat32)
+
+	for edge := range area.Corners {
+		start, end := area.GetEdge(edge)
+		closest := closestPointOnSegment(x, y, start, end)
+
+		if currDistance := distance2D(x, y, closest); currDistance < bestDistance {
+			best = closest
+			bestDistance = currDistance
+		}
+	}
+
+	return best
+}
+
+// closestPointOnSegment gets the point on the segment closest to the specified XY point.
+// The Z-coord is interpolated along the segment.
+func closestPointOnSegment(x, y float32, start, end Vector3) Vector3 {
+	dx := end.X - start.X
+	dy := end.Y - start.Y
+	lengthSquared := dx*dx + dy*dy
+
+	if lengthSquared == 0 {
+		return start
+	}
+
+	t := ((x-start.X)*dx + (y-start.Y)*dy) / lengthSquared
+	t = float32(math.Max(0, math.Min(1, float64(t))))
+
+	return Vector3{start.X + dx*t, start.Y + dy*t, start.Z + (end.Z-start.Z)*t}
+}
+
+// distance2D gets the distance between the specified XY point and the XY of the specified point
+func distance2D(x, y float32, point Vector3) float32 {
+	dx := point.X - x
+	dy := point.Y - y
+	return float32(math.Sqrt(float64(dx*dx + dy*dy)))
+}
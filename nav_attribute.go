@@ -0,0 +1,165 @@
+/*
+	gonav - A Source Engine navigation mesh file parser written in Go.
+	Copyright (C) 2016  Matt Razza
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as published
+	by the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package gonav provides functionality related to CS:GO Nav Meshes
+package gonav
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// NavAttribute represents the attribute bits (NAV_MESH_*) stored in NavArea.Flags
+type NavAttribute uint32
+
+const (
+	NavAttributeCrouch      NavAttribute = 0x00000001 // Must crouch to use this area
+	NavAttributeJump        NavAttribute = 0x00000002 // Must jump to traverse this area
+	NavAttributePrecise     NavAttribute = 0x00000004 // Do not adjust for obstacles, just move along the area
+	NavAttributeNoJump      NavAttribute = 0x00000008 // Inhibit discontinuity jumping
+	NavAttributeStop        NavAttribute = 0x00000010 // Must stop when entering this area
+	NavAttributeRun         NavAttribute = 0x00000020 // Must run to traverse this area
+	NavAttributeWalk        NavAttribute = 0x00000040 // Must walk to traverse this area
+	NavAttributeAvoid       NavAttribute = 0x00000080 // Avoid this area unless alternatives are too dangerous
+	NavAttributeTransient   NavAttribute = 0x00000100 // Area may become blocked, and should be periodically checked
+	NavAttributeDontHide    NavAttribute = 0x00000200 // Area should not be considered for hiding spot generation
+	NavAttributeStand       NavAttribute = 0x00000400 // Bots hiding in this area should stand
+	NavAttributeNoHostages  NavAttribute = 0x00000800 // Hostages shouldn't use this area
+	NavAttributeStairs      NavAttribute = 0x00001000 // This area represents stairs
+	NavAttributeNoMerge     NavAttribute = 0x00002000 // Don't merge this area with adjacent areas
+	NavAttributeObstacleTop NavAttribute = 0x00004000 // This nav area is the climb point on the tip of an obstacle
+	NavAttributeCliff       NavAttribute = 0x00008000 // This nav area is adjacent to a drop of at least CliffHeight
+)
+
+// navAttributeNames are the names of the attributes in bit order, as used by the Source engine
+var navAttributeNames = []string{
+	"CROUCH", "JUMP", "PRECISE", "NO_JUMP", "STOP", "RUN", "WALK", "AVOID",
+	"TRANSIENT", "DONT_HIDE", "STAND", "NO_HOSTAGES", "STAIRS", "NO_MERGE", "OBSTACLE_TOP", "CLIFF"}
+
+// String converts a NavAttribute into a human readable string such as "CROUCH|JUMP"
+func (attributes NavAttribute) String() string {
+	if attributes == 0 {
+		return "NONE"
+	}
+
+	var names []string
+
+	for bit, name := range navAttributeNames {
+		if attributes&(1<<uint(bit)) != 0 {
+			names = append(names, name)
+		}
+	}
+
+	if unknown := attributes &^ (1<<uint(len(navAttributeNames)) - 1); unknown != 0 {
+		names = append(names, fmt.Sprintf("0x%x", uint32(unknown)))
+	}
+
+	return strings.Join(names, "|")
+}
+
+// HasAttribute determines whether or not all of the specified attribute bits are set
+func (attributes NavAttribute) HasAttribute(attribute NavAttribute) bool {
+	return attributes&attribute == attribute
+}
+
+// NavAttributeMask gets the attribute bits a nav file with the specified major version can store.
+// Versions up to 8 store a byte and versions below 13 store a uint16.
+func NavAttributeMask(majorVersion uint32) NavAttribute {
+	if majorVersion <= 8 {
+		return 0xFF
+	} else if majorVersion < 13 {
+		return 0xFFFF
+	}
+
+	return 0xFFFFFFFF
+}
+
+// Attributes gets the attribute bits of this area
+func (area *NavArea) Attributes() NavAttribute {
+	return NavAttribute(area.Flags)
+}
+
+// HasAttribute determines whether or not this area has all of the specified attribute bits set
+func (area *NavArea) HasAttribute(attribute NavAttribute) bool {
+	return area.Attributes().HasAttribute(attribute)
+}
+
+// AttributePenalties maps attributes to the multiplier applied to the cost of moving into an area with them.
+// A multiplier of +Inf forbids moving into the area.
+type AttributePenalties map[NavAttribute]float32
+
+// DefaultAttributePenalties gets penalties that make areas which slow a player down more expensive
+func DefaultAttributePenalties() AttributePenalties {
+	return AttributePenalties{
+		NavAttributeCrouch: 2,
+		NavAttributeJump:   2,
+		NavAttributeWalk:   2,
+		NavAttributeAvoid:  10}
+}
+
+// AttributeConnectionCalculator creates a MeshConnectionCalculator for BuildShortestPath that costs a connection
+// by the distance between the centers of its areas, multiplied by the penalty of every attribute of the target area.
+// Connections into areas with a forbidden attribute cost +Inf and are never used.
+func AttributeConnectionCalculator(penalties AttributePenalties) MeshConnectionCalculator {
+	order := penalties.sortedAttributes()
+
+	return func(con *NavConnection) float32 {
+		multiplier := penalties.multiplier(con.TargetArea, order)
+		if math.IsInf(float64(multiplier), 1) {
+			return multiplier
+		}
+
+		distance := con.SourceArea.GetCenter()
+		distance.Sub(con.TargetArea.GetCenter())
+		return distance.Length() * multiplier
+	}
+}
+
+// sortedAttributes gets the attributes that have penalties in ascending order. Multiplying the penalties in this
+// order, rather than the order of the map, gives the same product every time.
+func (penalties AttributePenalties) sortedAttributes() []NavAttribute {
+	attributes := make([]NavAttribute, 0, len(penalties))
+	for currAttribute := range penalties {
+		attributes = append(attributes, currAttribute)
+	}
+
+	sort.Slice(attributes, func(i, j int) bool { return attributes[i] < attributes[j] })
+	return attributes
+}
+
+// multiplier gets the product of the penalties of every attribute of the specified area, multiplied in the order of
+// the specified attributes as given by sortedAttributes; +Inf if any is forbidden
+func (penalties AttributePenalties) multiplier(area *NavArea, order []NavAttribute) float32 {
+	attributes := area.Attributes()
+	multiplier := float32(1)
+
+	for _, currAttribute := range order {
+		if attributes.HasAttribute(currAttribute) {
+			currPenalty := penalties[currAttribute]
+			if math.IsInf(float64(currPenalty), 1) {
+				return currPenalty
+			}
+
+			multiplier *= currPenalty
+		}
+	}
+
+	return multiplier
+}
@@ -0,0 +1,55 @@
+/*
+	gonav - A Source Engine navigation mesh file parser written in Go.
+	Copyright (C) 2016  Matt Razza
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as published
+	by the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package gonav provides functionality related to CS:GO Nav Meshes
+package gonav
+
+import "testing"
+
+func TestAttributeMultiplierOrder(t *testing.T) {
+	penalties := AttributePenalties{
+		NavAttributeCrouch: 1.1,
+		NavAttributeJump:   1.3e7,
+		NavAttributeWalk:   1.7,
+		NavAttributeAvoid:  3.3e-5,
+		NavAttributeStairs: 2.9,
+		NavAttributeStop:   7.1e-3}
+	area := &NavArea{NorthWest: Vector3{2, 0, 0}, SouthEast: Vector3{4, 2, 0}, Flags: uint32(NavAttributeCrouch | NavAttributeJump | NavAttributeWalk | NavAttributeAvoid | NavAttributeStairs | NavAttributeStop)}
+
+	// Multiplied in ascending order of attribute
+	expected := float32(1)
+	for _, currAttribute := range []NavAttribute{NavAttributeCrouch, NavAttributeJump, NavAttributeStop, NavAttributeWalk, NavAttributeAvoid, NavAttributeStairs} {
+		expected *= penalties[currAttribute]
+	}
+
+	// Map iteration order changes from one call to the next, so a product in that order would eventually differ
+	for i := 0; i < 100; i++ {
+		if multiplier := penalties.multiplier(area, penalties.sortedAttributes()); multiplier != expected {
+			t.Fatalf("Multiplier is %v, expected %v.", multiplier, expected)
+		}
+	}
+
+	calculator := AttributeConnectionCalculator(penalties)
+	source := &NavArea{SouthEast: Vector3{2, 2, 0}}
+	connection := &NavConnection{SourceArea: source, TargetArea: area}
+
+	// The centers of the areas are 2 units apart
+	if cost := calculator(connection); cost != 2*expected {
+		t.Errorf("Connection costs %v, expected %v.", cost, 2*expected)
+	}
+}
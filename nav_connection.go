@@ -19,6 +19,11 @@
 // Package gonav provides functionality related to CS:GO Nav Meshes
 package gonav
 
+import (
+	"fmt"
+	"math"
+)
+
 // NavDirection represents a cardinal direction
 type NavDirection int
 
@@ -39,14 +44,357 @@ const (
 	NavDirectionMax
 )
 
+// Opposite gets the direction facing the other way; NavDirectionNorth becomes NavDirectionSouth
+func (direction NavDirection) Opposite() NavDirection {
+	return (direction + 2) % NavDirectionMax
+}
+
+// NavConnectionKind classifies a NavConnection as bidirectional or one of the kinds of one-way connection
+type NavConnectionKind int
+
+const (
+	// NavConnectionBidirectional means the target area connects back to the source area
+	NavConnectionBidirectional NavConnectionKind = iota
+
+	// NavConnectionOneWay means there is no connection back, but no drop or climb between the areas either
+	NavConnectionOneWay
+
+	// NavConnectionOneWayDrop means there is no connection back because the target area is more than a step below
+	NavConnectionOneWayDrop
+
+	// NavConnectionOneWayJump means there is no connection back and the target area is more than a step above
+	NavConnectionOneWayJump
+)
+
+// String converts a NavConnectionKind into a human readable string
+func (kind NavConnectionKind) String() string {
+	switch kind {
+	case NavConnectionBidirectional:
+		return "bidirectional"
+	case NavConnectionOneWay:
+		return "one-way"
+	case NavConnectionOneWayDrop:
+		return "one-way drop"
+	case NavConnectionOneWayJump:
+		return "one-way jump"
+	}
+
+	return fmt.Sprintf("NavConnectionKind(%d)", int(kind))
+}
+
 // NavConnection represents a connection between two NavAreas
 type NavConnection struct {
-	SourceArea   *NavArea     // The starting area for this connection
-	TargetAreaID uint32       // The ID of the target area for this NavConnection
-	TargetArea   *NavArea     // The target area for this connection
-	Direction    NavDirection // The direction of the connection between these two areas
+	SourceArea   *NavArea          // The starting area for this connection
+	TargetAreaID uint32            // The ID of the target area for this NavConnection
+	TargetArea   *NavArea          // The target area for this connection
+	Direction    NavDirection      // The direction of the connection between these two areas
+	Edge         int               // For polygonal areas, the index of the edge of SourceArea this connection crosses
+	TargetEdge   uint32            // For polygonal areas, the edge of the target area as stored in the nav file
+	Kind         NavConnectionKind // Whether the target area connects back and, if it doesn't, why
+
+	portalLeft   Vector3 // The left end of the portal, cached by computePortal
+	portalRight  Vector3 // The right end of the portal, cached by computePortal
+	targetLeftZ  float32 // The Z of the target area at the left end of the portal, cached by computePortal
+	targetRightZ float32 // The Z of the target area at the right end of the portal, cached by computePortal
+	levelChange  bool    // Whether the height change across the portal is more than a step, cached by classify
 }
 
 func (conn *NavConnection) connectGraph(mesh *NavMesh) {
 	conn.TargetArea = mesh.Areas[conn.TargetAreaID]
+	conn.computePortal()
+}
+
+// Portal gets the portal of this connection: the part of the edge of the source area, facing the target area, that
+// the target area overlaps. The ends are returned left first, then right, as seen when crossing from the source area
+// into the target area. Their Z comes from the source area's GetZ; TargetPortal gets them with the target area's Z.
+// The portal is cached when the graph is connected and by the NavMesh editing methods; it is a single point when
+// the areas do not overlap along the edge.
+func (conn *NavConnection) Portal() (Vector3, Vector3) {
+	return conn.portalLeft, conn.portalRight
+}
+
+// TargetPortal gets the portal of this connection on the target area's side: the same ends as Portal, left first,
+// with their Z taken from the target area instead. The two differ where the connection steps or drops.
+func (conn *NavConnection) TargetPortal() (Vector3, Vector3) {
+	left, right := conn.portalLeft, conn.portalRight
+	left.Z, right.Z = conn.targetLeftZ, conn.targetRightZ
+	return left, right
+}
+
+// entryFrom gets the point the target area is entered at when this connection is taken from the specified point:
+// the point on the portal closest to it, moved into the target area
+func (conn *NavConnection) entryFrom(point Vector3) Vector3 {
+	left, right := conn.portalLeft, conn.portalRight
+	portal := Vector3{right.X - left.X, right.Y - left.Y, 0}
+	t := float32(0)
+
+	if length := portal.LengthSquared(); length > 0 {
+		t = ((point.X-left.X)*portal.X + (point.Y-left.Y)*portal.Y) / length
+		t = float32(math.Max(0, math.Min(1, float64(t))))
+	}
+
+	return conn.TargetArea.GetClosestPointInArea(Vector3{lerp(left.X, right.X, t), lerp(left.Y, right.Y, t), 0})
+}
+
+// computePortal computes and caches the portal of this connection
+func (conn *NavConnection) computePortal() {
+	source, target := conn.SourceArea, conn.TargetArea
+	if source == nil || target == nil {
+		conn.portalLeft, conn.portalRight = Vector3{}, Vector3{}
+		conn.targetLeftZ, conn.targetRightZ = 0, 0
+		return
+	}
+
+	var start, end, forward Vector3
+
+	if source.IsPolygon() {
+		if conn.Edge < 0 || conn.Edge >= len(source.Corners) {
+			conn.portalLeft, conn.portalRight = source.GetCenter(), source.GetCenter()
+			conn.targetLeftZ = target.GetClosestPointInArea(conn.portalLeft).Z
+			conn.targetRightZ = conn.targetLeftZ
+			return
+		}
+
+		start, end = source.GetEdge(conn.Edge)
+		forward = Vector3{(start.X + end.X) / 2, (start.Y + end.Y) / 2, 0}
+		forward.Sub(source.polygonAverage())
+	} else {
+		// Clip the facing edge to the part the target overlaps
+		clip := func(min, max, otherMin, otherMax float32) (float32, float32) {
+			low := float32(math.Max(float64(min), float64(otherMin)))
+			high := float32(math.Min(float64(max), float64(otherMax)))
+
+			if low > high {
+				// No overlap; use the end of the edge nearest the target
+				if otherMin > max {
+					return max, max
+				}
+
+				return min, min
+			}
+
+			return low, high
+		}
+
+		switch conn.Direction {
+		case NavDirectionNorth, NavDirectionSouth:
+			low, high := clip(source.NorthWest.X, source.SouthEast.X, target.NorthWest.X, target.SouthEast.X)
+			y := source.NorthWest.Y
+			forward = Vector3{0, -1, 0}
+
+			if conn.Direction == NavDirectionSouth {
+				y = source.SouthEast.Y
+				forward = Vector3{0, 1, 0}
+			}
+
+			start, end = Vector3{low, y, 0}, Vector3{high, y, 0}
+
+		default:
+			low, high := clip(source.NorthWest.Y, source.SouthEast.Y, target.NorthWest.Y, target.SouthEast.Y)
+			x := source.SouthEast.X
+			forward = Vector3{1, 0, 0}
+
+			if conn.Direction == NavDirectionWest {
+				x = source.NorthWest.X
+				forward = Vector3{-1, 0, 0}
+			}
+
+			start, end = Vector3{x, low, 0}, Vector3{x, high, 0}
+		}
+
+		start.Z = clampedZ(source, start)
+		end.Z = clampedZ(source, end)
+	}
+
+	// Left of the direction of travel, with Z up, is the travel direction turned a quarter turn anticlockwise
+	left := Vector3{-forward.Y, forward.X, 0}
+
+	if start.X*left.X+start.Y*left.Y < end.X*left.X+end.Y*left.Y {
+		start, end = end, start
+	}
+
+	conn.portalLeft, conn.portalRight = start, end
+	conn.targetLeftZ = target.GetClosestPointInArea(start).Z
+	conn.targetRightZ = target.GetClosestPointInArea(end).Z
+}
+
+// IsOneWay determines whether or not the target area of this connection has no connection back
+func (conn *NavConnection) IsOneWay() bool {
+	return conn.Kind != NavConnectionBidirectional
+}
+
+// classify sets the Kind of this connection from whether there is a connection back and the height change
+// at the middle of the portal. Height changes of more than stepHeight are drops or jumps. The portal must
+// already be computed.
+func (conn *NavConnection) classify(stepHeight float32) {
+	if conn.TargetArea == nil || conn.SourceArea == nil {
+		conn.Kind = NavConnectionOneWay
+		conn.levelChange = false
+		return
+	}
+
+	rise := conn.rise()
+	conn.levelChange = rise < -stepHeight || rise > stepHeight
+
+	switch {
+	case conn.TargetArea.IsConnectedTo(conn.SourceArea):
+		conn.Kind = NavConnectionBidirectional
+	case rise < -stepHeight:
+		conn.Kind = NavConnectionOneWayDrop
+	case rise > stepHeight:
+		conn.Kind = NavConnectionOneWayJump
+	default:
+		conn.Kind = NavConnectionOneWay
+	}
+}
+
+// rise gets the height change across the middle of the portal of this connection, negative when the target
+// area is below the source area
+func (conn *NavConnection) rise() float32 {
+	source, target := conn.portalMiddles()
+	return target.Z - source.Z
+}
+
+// portalMiddles gets the middle of the portal of this connection on the source area's side, then on the target's
+func (conn *NavConnection) portalMiddles() (Vector3, Vector3) {
+	left, right := conn.portalLeft, conn.portalRight
+	source := Vector3{(left.X + right.X) / 2, (left.Y + right.Y) / 2, (left.Z + right.Z) / 2}
+	target := source
+	target.Z = (conn.targetLeftZ + conn.targetRightZ) / 2
+
+	return source, target
+}
+
+// IncomingConnections gets the connections from other areas to this area. The slice must not be modified.
+func (area *NavArea) IncomingConnections() []*NavConnection {
+	return area.incoming
+}
+
+// IsConnectedTo determines whether or not this area has a connection to the specified area
+func (area *NavArea) IsConnectedTo(other *NavArea) bool {
+	for _, currConnection := range area.Connections {
+		if currConnection.TargetArea == other {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetConnectionsTo gets the connections from this area to the specified area
+func (area *NavArea) GetConnectionsTo(other *NavArea) []*NavConnection {
+	var connections []*NavConnection
+
+	for _, currConnection := range area.Connections {
+		if currConnection.TargetArea == other {
+			connections = append(connections, currConnection)
+		}
+	}
+
+	return connections
+}
+
+// GetConnections gets the connections from this area in the specified direction
+func (area *NavArea) GetConnections(direction NavDirection) []*NavConnection {
+	var connections []*NavConnection
+
+	for _, currConnection := range area.Connections {
+		if currConnection.Direction == direction {
+			connections = append(connections, currConnection)
+		}
+	}
+
+	return connections
+}
+
+// linkIncoming rebuilds the incoming connections of the specified areas from the connections between them
+func linkIncoming(areas []*NavArea) {
+	for _, currArea := range areas {
+		currArea.incoming = currArea.incoming[:0]
+	}
+
+	for _, currArea := range areas {
+		for _, currConnection := range currArea.Connections {
+			if currConnection.TargetArea != nil {
+				currConnection.TargetArea.incoming = append(currConnection.TargetArea.incoming, currConnection)
+			}
+		}
+	}
+
+	// Keep the order stable no matter what order the areas are in.
+	// The lists are short, so a simple insertion sort beats sort.Slice
+	for _, currArea := range areas {
+		incoming := currArea.incoming
+		for i := 1; i < len(incoming); i++ {
+			for j := i; j > 0 && incoming[j].SourceArea.ID < incoming[j-1].SourceArea.ID; j-- {
+				incoming[j], incoming[j-1] = incoming[j-1], incoming[j]
+			}
+		}
+	}
+}
+
+// relinkIncoming rebuilds the incoming connections of every area in this mesh and classifies every connection
+func (mesh *NavMesh) relinkIncoming() {
+	stepHeight := mesh.stepHeight()
+	areas := make([]*NavArea, 0, len(mesh.Areas))
+	for _, currArea := range mesh.Areas {
+		areas = append(areas, currArea)
+	}
+
+	linkIncoming(areas)
+
+	for _, currArea := range areas {
+		for _, currConnection := range currArea.Connections {
+			currConnection.computePortal()
+			currConnection.classify(stepHeight)
+		}
+	}
+}
+
+// removeIncoming removes this connection from the incoming connections of its target
+func (conn *NavConnection) removeIncoming() {
+	if conn.TargetArea == nil {
+		return
+	}
+
+	for i, currConnection := range conn.TargetArea.incoming {
+		if currConnection == conn {
+			conn.TargetArea.incoming = append(conn.TargetArea.incoming[:i:i], conn.TargetArea.incoming[i+1:]...)
+			return
+		}
+	}
+}
+
+// link adds a new connection to the incoming connections of its target and classifies it and any connection back
+func (conn *NavConnection) link(stepHeight float32) {
+	conn.computePortal()
+
+	if conn.TargetArea == nil {
+		conn.classify(stepHeight)
+		return
+	}
+
+	conn.TargetArea.incoming = append(conn.TargetArea.incoming, conn)
+	conn.classify(stepHeight)
+
+	for _, currConnection := range conn.TargetArea.Connections {
+		if currConnection.TargetArea == conn.SourceArea {
+			currConnection.classify(stepHeight)
+		}
+	}
+}
+
+// unlink removes a connection from the incoming connections of its target and classifies any connection back
+func (conn *NavConnection) unlink(stepHeight float32) {
+	if conn.TargetArea == nil {
+		return
+	}
+
+	conn.removeIncoming()
+
+	for _, currConnection := range conn.TargetArea.Connections {
+		if currConnection.TargetArea == conn.SourceArea {
+			currConnection.classify(stepHeight)
+		}
+	}
 }
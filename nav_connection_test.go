@@ -0,0 +1,115 @@
+/*
+	gonav - A Source Engine navigation mesh file parser written in Go.
+	Copyright (C) 2016  Matt Razza
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as published
+	by the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package gonav provides functionality related to CS:GO Nav Meshes
+package gonav
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestClassifyUsesStepHeight(t *testing.T) {
+	for _, test := range []struct {
+		opts ConnectionOptions
+		kind NavConnectionKind
+	}{
+		{DefaultConnectionOptions(), NavConnectionOneWayDrop},
+		{ConnectionOptions{StepHeight: 40, EdgeTolerance: 1}, NavConnectionOneWay},
+	} {
+		parser := Parser{Reader: bytes.NewReader(writeTestMesh(t, newTestMesh(3), 16))}
+		mesh, err := parser.Parse()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Raise area 2 30 units above area 1, above the default step height but within the custom one
+		if err := mesh.MoveArea(mesh.Areas[2], Vector3{0, 0, 30}); err != nil {
+			t.Fatal(err)
+		}
+
+		mesh.RebuildConnections(test.opts)
+
+		// Only the custom step height is high enough to link area 1 up to area 2
+		if mesh.Areas[1].IsConnectedTo(mesh.Areas[2]) {
+			if err := mesh.Disconnect(mesh.Areas[1], mesh.Areas[2]); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		connections := mesh.Areas[2].GetConnectionsTo(mesh.Areas[1])
+		if len(connections) != 1 {
+			t.Fatalf("Area 2 has %v connections to area 1.", len(connections))
+		}
+
+		if connections[0].Kind != test.kind {
+			t.Errorf("Connection with a step height of %v is %v, expected %v.", test.opts.StepHeight, connections[0].Kind, test.kind)
+		}
+	}
+}
+
+func TestPortalHeights(t *testing.T) {
+	mesh := parseTestMesh(t, 3)
+
+	// Area 1 is 1 unit high along its east edge and area 2 is 31 units high along its west edge
+	if err := mesh.MoveArea(mesh.Areas[2], Vector3{0, 0, 30}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, currCase := range []struct {
+		from, to         uint32
+		sourceZ, targetZ float32
+	}{
+		{1, 2, 1, 31},
+		{2, 1, 31, 1},
+	} {
+		connection := mesh.Areas[currCase.from].GetConnectionsTo(mesh.Areas[currCase.to])[0]
+		left, right := connection.Portal()
+		targetLeft, targetRight := connection.TargetPortal()
+
+		if left.Z != currCase.sourceZ || right.Z != currCase.sourceZ {
+			t.Errorf("Portal from area %v to area %v runs from %v to %v, expected Z %v.", currCase.from, currCase.to, left, right, currCase.sourceZ)
+		}
+
+		if targetLeft.Z != currCase.targetZ || targetRight.Z != currCase.targetZ {
+			t.Errorf("Target portal from area %v to area %v runs from %v to %v, expected Z %v.", currCase.from, currCase.to, targetLeft, targetRight, currCase.targetZ)
+		}
+
+		if targetLeft.X != left.X || targetLeft.Y != left.Y || targetRight.X != right.X || targetRight.Y != right.Y {
+			t.Errorf("Target portal from area %v to area %v is not the same segment as the portal.", currCase.from, currCase.to)
+		}
+
+		if rise := connection.rise(); rise != currCase.targetZ-currCase.sourceZ || !connection.levelChange {
+			t.Errorf("Connection from area %v to area %v rises %v.", currCase.from, currCase.to, rise)
+		}
+	}
+}
+
+func BenchmarkRelinkIncoming(b *testing.B) {
+	parser := Parser{Reader: bytes.NewReader(writeTestMesh(b, newTestMesh(200), 16))}
+	mesh, err := parser.Parse()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		mesh.relinkIncoming()
+	}
+}
@@ -19,8 +19,12 @@
 // Package gonav provides functionality related to CS:GO Nav Meshes
 package gonav
 
-// NavEncounterPath represents an encounter path
+import "math"
+
+// NavEncounterPath represents an encounter path: a way of crossing an area from one neighbour to another,
+// and the hiding spots to watch while doing so
 type NavEncounterPath struct {
+	Area          *NavArea            // The area this path crosses
 	FromAreaID    uint32              // The ID of the area the path comes from
 	FromArea      *NavArea            // The Area the path comes from
 	FromDirection NavDirection        // The direction from the source
@@ -32,11 +36,105 @@ type NavEncounterPath struct {
 
 // NavEncounterSpot represents a spot along an encounter path
 type NavEncounterSpot struct {
-	OrderID             uint32  // The ID of the order of this spot
-	ParametricDistiance float32 // The parametric distance
+	OrderID             uint32         // The ID of the hiding spot to watch
+	ParametricDistiance float32        // How far along the path, from 0 to 1, the spot should be watched
+	HidingSpot          *NavHidingSpot // The hiding spot to watch; nil if there is no hiding spot with OrderID
+	Position            Vector3        // The point along the path at which the spot should be watched
 }
 
-func (path *NavEncounterPath) connectGraph(mesh *NavMesh) {
+func (path *NavEncounterPath) connectGraph(mesh *NavMesh, area *NavArea, spots *hidingSpotIndex) {
+	path.Area = area
 	path.FromArea = mesh.Areas[path.FromAreaID]
 	path.ToArea = mesh.Areas[path.ToAreaID]
+
+	// The path runs from the middle of the edge shared with the from area to the middle of the edge shared with the to area
+	start := area.edgeMidpoint(path.FromArea, path.FromDirection)
+	end := area.edgeMidpoint(path.ToArea, path.ToDirection)
+
+	for _, currSpot := range path.Spots {
+		currSpot.HidingSpot = spots.byID[currSpot.OrderID]
+		currSpot.Position = Vector3{
+			X: lerp(start.X, end.X, currSpot.ParametricDistiance),
+			Y: lerp(start.Y, end.Y, currSpot.ParametricDistiance),
+			Z: lerp(start.Z, end.Z, currSpot.ParametricDistiance)}
+	}
+}
+
+// relinkEncounterPaths resolves the encounter paths of this area again after an edit has moved it or its
+// neighbours, or removed hiding spots
+func (area *NavArea) relinkEncounterPaths(mesh *NavMesh) {
+	if len(area.EncounterPaths) == 0 {
+		return
+	}
+
+	spots := mesh.hidingSpotIndex()
+
+	for _, currPath := range area.EncounterPaths {
+		currPath.connectGraph(mesh, area, spots)
+	}
+}
+
+// GetEncounterSpots gets the spots to watch when crossing this area from one neighbouring area to another;
+// nil if there is no encounter path between them
+func (area *NavArea) GetEncounterSpots(from *NavArea, to *NavArea) []*NavEncounterSpot {
+	for _, currPath := range area.EncounterPaths {
+		if currPath.FromArea == from && currPath.ToArea == to {
+			return currPath.Spots
+		}
+	}
+
+	return nil
+}
+
+// edgeMidpoint gets the middle of the part of the edge of this area, in the specified direction, that borders the
+// other area. Polygonal areas use the edge of their connection to the other area. Without a neighbour the whole
+// edge is used, and a polygon without a connection to the other area uses its center.
+func (area *NavArea) edgeMidpoint(other *NavArea, direction NavDirection) Vector3 {
+	if area.IsPolygon() {
+		for _, currConnection := range area.Connections {
+			if other != nil && currConnection.TargetArea == other && currConnection.Edge < len(area.Corners) {
+				start, end := area.GetEdge(currConnection.Edge)
+				return Vector3{(start.X + end.X) / 2, (start.Y + end.Y) / 2, (start.Z + end.Z) / 2}
+			}
+		}
+
+		return area.GetCenter()
+	}
+
+	// Clamp the edge to the part the other area overlaps; an area overlaps itself completely
+	otherNorthWest, otherSouthEast := area.NorthWest, area.SouthEast
+	if other != nil {
+		otherNorthWest, otherSouthEast = other.NorthWest, other.SouthEast
+	}
+
+	clamp := func(min, max, otherMin, otherMax float32) float32 {
+		if otherMin > max || otherMax < min {
+			return (min + max) / 2
+		}
+
+		return (float32(math.Max(float64(min), float64(otherMin))) + float32(math.Min(float64(max), float64(otherMax)))) / 2
+	}
+
+	var point Vector3
+
+	switch direction {
+	case NavDirectionNorth, NavDirectionSouth:
+		point.X = clamp(area.NorthWest.X, area.SouthEast.X, otherNorthWest.X, otherSouthEast.X)
+		point.Y = area.NorthWest.Y
+
+		if direction == NavDirectionSouth {
+			point.Y = area.SouthEast.Y
+		}
+
+	default:
+		point.X = area.SouthEast.X
+		point.Y = clamp(area.NorthWest.Y, area.SouthEast.Y, otherNorthWest.Y, otherSouthEast.Y)
+
+		if direction == NavDirectionWest {
+			point.X = area.NorthWest.X
+		}
+	}
+
+	point.Z = clampedZ(area, point)
+	return point
 }
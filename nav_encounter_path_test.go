@@ -0,0 +1,67 @@
+/*
+	gonav - A Source Engine navigation mesh file parser written in Go.
+	Copyright (C) 2016  Matt Razza
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as published
+	by the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package gonav provides functionality related to CS:GO Nav Meshes
+package gonav
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncounterSpotsFollowEdits(t *testing.T) {
+	source := newTestMesh(3)
+
+	// Area 1 also watches the hiding spot in area 5
+	source.Areas[1].EncounterPaths = append(source.Areas[1].EncounterPaths, &NavEncounterPath{
+		FromAreaID:    2,
+		FromDirection: NavDirectionEast,
+		ToAreaID:      4,
+		ToDirection:   NavDirectionSouth,
+		Spots:         []*NavEncounterSpot{{OrderID: 7}}})
+
+	parser := Parser{Reader: bytes.NewReader(writeTestMesh(t, source, 16))}
+	mesh, err := parser.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spot := mesh.Areas[5].EncounterPaths[0].Spots[0]
+	before := spot.Position
+
+	if err := mesh.MoveArea(mesh.Areas[5], Vector3{0, 0, 10}); err != nil {
+		t.Fatal(err)
+	}
+
+	if spot.Position.Z != before.Z+10 {
+		t.Errorf("Encounter spot is at %v after moving its area up from %v.", spot.Position, before)
+	}
+
+	watched := mesh.Areas[1].EncounterPaths[0].Spots[0]
+	if watched.HidingSpot == nil {
+		t.Fatal("Encounter spot was not resolved to the hiding spot.")
+	}
+
+	if err := mesh.RemoveArea(mesh.Areas[5]); err != nil {
+		t.Fatal(err)
+	}
+
+	if watched.HidingSpot != nil {
+		t.Error("Encounter spot still watches a hiding spot that was removed with its area.")
+	}
+}
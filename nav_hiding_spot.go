@@ -0,0 +1,217 @@
+/*
+	gonav - A Source Engine navigation mesh file parser written in Go.
+	Copyright (C) 2016  Matt Razza
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as published
+	by the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package gonav provides functionality related to CS:GO Nav Meshes
+package gonav
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// NavHidingSpotFlags represents the bitflags of a NavHidingSpot
+type NavHidingSpotFlags byte
+
+const (
+	NavHidingSpotInCover         NavHidingSpotFlags = 0x01 // The spot is in a corner with good hard cover nearby
+	NavHidingSpotGoodSniperSpot  NavHidingSpotFlags = 0x02 // The spot has at least one decent sniping line
+	NavHidingSpotIdealSniperSpot NavHidingSpotFlags = 0x04 // The spot has a commanding view of a large area
+	NavHidingSpotExposed         NavHidingSpotFlags = 0x08 // The spot is not safe to hide in
+)
+
+// hidingSpotCellSize is the width and height of the cells of the hiding spot index
+const hidingSpotCellSize float32 = 256
+
+// navHidingSpotFlagNames are the names of the flags in bit order, as used by the Source engine
+var navHidingSpotFlagNames = []string{"IN_COVER", "GOOD_SNIPER_SPOT", "IDEAL_SNIPER_SPOT", "EXPOSED"}
+
+// String converts a NavHidingSpotFlags into a human readable string such as "IN_COVER|EXPOSED"
+func (flags NavHidingSpotFlags) String() string {
+	if flags == 0 {
+		return "NONE"
+	}
+
+	var names []string
+
+	for bit, name := range navHidingSpotFlagNames {
+		if flags&(1<<uint(bit)) != 0 {
+			names = append(names, name)
+		}
+	}
+
+	if unknown := flags &^ (1<<uint(len(navHidingSpotFlagNames)) - 1); unknown != 0 {
+		names = append(names, fmt.Sprintf("0x%x", byte(unknown)))
+	}
+
+	return strings.Join(names, "|")
+}
+
+// HasFlags determines whether or not all of the specified flags are set
+func (flags NavHidingSpotFlags) HasFlags(other NavHidingSpotFlags) bool {
+	return flags&other == other
+}
+
+// hidingSpotIndex is a grid of the hiding spots in a mesh used to search them by position
+type hidingSpotIndex struct {
+	spots []*NavHidingSpot              // Every hiding spot, ordered by ID
+	byID  map[uint32]*NavHidingSpot     // Hiding spots by ID
+	cells map[[2]int32][]*NavHidingSpot // Hiding spots by the grid cell they are in
+}
+
+// newHidingSpotIndex indexes every hiding spot in the specified mesh
+func newHidingSpotIndex(mesh *NavMesh) *hidingSpotIndex {
+	index := &hidingSpotIndex{
+		byID:  make(map[uint32]*NavHidingSpot),
+		cells: make(map[[2]int32][]*NavHidingSpot)}
+
+	for _, currArea := range mesh.Areas {
+		for _, currSpot := range currArea.HidingSpots {
+			currSpot.Area = currArea
+			index.spots = append(index.spots, currSpot)
+		}
+	}
+
+	sort.Slice(index.spots, func(i, j int) bool { return index.spots[i].ID < index.spots[j].ID })
+
+	for _, currSpot := range index.spots {
+		index.byID[currSpot.ID] = currSpot
+
+		if isFinite(currSpot.Location.X) && isFinite(currSpot.Location.Y) {
+			cell := hidingSpotCell(currSpot.Location.X, currSpot.Location.Y)
+			index.cells[cell] = append(index.cells[cell], currSpot)
+		}
+	}
+
+	return index
+}
+
+// hidingSpotCell gets the grid cell of the hiding spot index containing the specified point. Points too far out
+// for the grid are clamped into the cells at its edge.
+func hidingSpotCell(x, y float32) [2]int32 {
+	cell := func(value float32) int32 {
+		return int32(math.Max(math.MinInt32, math.Min(math.MaxInt32, math.Floor(float64(value)/float64(hidingSpotCellSize)))))
+	}
+
+	return [2]int32{cell(x), cell(y)}
+}
+
+// hidingSpotIndex gets the hiding spot index of this mesh, building it if it has not been built since the last edit
+func (mesh *NavMesh) hidingSpotIndex() *hidingSpotIndex {
+	defer mesh.lockIndexes()()
+
+	if mesh.hidingSpots == nil {
+		mesh.hidingSpots = newHidingSpotIndex(mesh)
+	}
+
+	return mesh.hidingSpots
+}
+
+// HidingSpots gets every hiding spot in this mesh, ordered by ID.
+// Edits made through the NavMesh methods are picked up automatically; call InvalidateHidingSpots
+// after changing NavArea.HidingSpots by hand.
+func (mesh *NavMesh) HidingSpots() []*NavHidingSpot {
+	return append([]*NavHidingSpot(nil), mesh.hidingSpotIndex().spots...)
+}
+
+// GetHidingSpotByID gets the hiding spot with the specified ID; nil if there is no such spot
+func (mesh *NavMesh) GetHidingSpotByID(id uint32) *NavHidingSpot {
+	return mesh.hidingSpotIndex().byID[id]
+}
+
+// FindHidingSpotsInRadius finds the hiding spots within the specified distance of the specified point that have
+// all of the specified flags (0 matches every spot). The spots are ordered nearest first.
+func (mesh *NavMesh) FindHidingSpotsInRadius(point Vector3, radius float32, flags NavHidingSpotFlags) []*NavHidingSpot {
+	index := mesh.hidingSpotIndex()
+	radiusSquared := radius * radius
+	var spots []*NavHidingSpot
+	var distances []float32
+
+	consider := func(currSpot *NavHidingSpot) {
+		if !currSpot.Flags.HasFlags(flags) {
+			return
+		}
+
+		offset := currSpot.Location
+		offset.Sub(point)
+
+		if currDistance := offset.LengthSquared(); currDistance <= radiusSquared {
+			spots = append(spots, currSpot)
+			distances = append(distances, currDistance)
+		}
+	}
+
+	if radius < 0 || !isFinite(radius) || !isFinite(point.X) || !isFinite(point.Y) {
+		return nil
+	}
+
+	min := hidingSpotCell(point.X-radius, point.Y-radius)
+	max := hidingSpotCell(point.X+radius, point.Y+radius)
+
+	// Very large radii cover more cells than there are spots; look at every spot instead
+	// The count is worked out in floating point since the whole grid has more cells than fit in 64 bits
+	if cellCount := (float64(max[0]) - float64(min[0]) + 1) * (float64(max[1]) - float64(min[1]) + 1); cellCount > float64(len(index.cells)) {
+		for _, currSpot := range index.spots {
+			consider(currSpot)
+		}
+	} else {
+		// Loop in 64 bits so a range ending at the edge of the grid can't wrap around
+		for x := int64(min[0]); x <= int64(max[0]); x++ {
+			for y := int64(min[1]); y <= int64(max[1]); y++ {
+				for _, currSpot := range index.cells[[2]int32{int32(x), int32(y)}] {
+					consider(currSpot)
+				}
+			}
+		}
+	}
+
+	sort.Sort(spotsByDistance{spots, distances})
+	return spots
+}
+
+// InvalidateHidingSpots discards the hiding spot index of this mesh. It only needs to be called after
+// changing NavArea.HidingSpots or their locations directly rather than through the NavMesh methods.
+func (mesh *NavMesh) InvalidateHidingSpots() {
+	defer mesh.lockIndexes()()
+
+	mesh.hidingSpots = nil
+}
+
+// spotsByDistance sorts hiding spots by their distance from a point, then by ID
+type spotsByDistance struct {
+	spots     []*NavHidingSpot
+	distances []float32
+}
+
+func (s spotsByDistance) Len() int {
+	return len(s.spots)
+}
+
+func (s spotsByDistance) Less(i, j int) bool {
+	if s.distances[i] != s.distances[j] {
+		return s.distances[i] < s.distances[j]
+	}
+
+	return s.spots[i].ID < s.spots[j].ID
+}
+
+func (s spotsByDistance) Swap(i, j int) {
+	s.spots[i], s.spots[j] = s.spots[j], s.spots[i]
+	s.distances[i], s.distances[j] = s.distances[j], s.distances[i]
+}
@@ -0,0 +1,38 @@
+/*
+	gonav - A Source Engine navigation mesh file parser written in Go.
+	Copyright (C) 2016  Matt Razza
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as published
+	by the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package gonav provides functionality related to CS:GO Nav Meshes
+package gonav
+
+import "testing"
+
+func TestFindHidingSpotsInHugeRadius(t *testing.T) {
+	mesh := NavMesh{Areas: map[uint32]*NavArea{
+		1: {ID: 1, HidingSpots: []*NavHidingSpot{{ID: 1, Location: Vector3{5, 5, 0}}}},
+		2: {ID: 2, HidingSpots: []*NavHidingSpot{{ID: 2, Location: Vector3{3e10, -3e10, 0}}}}}}
+
+	for radius, expected := range map[float32]int{1000: 1, 1e12: 2, 3e38: 2} {
+		if count := len(mesh.FindHidingSpotsInRadius(Vector3{}, radius, 0)); count != expected {
+			t.Errorf("Found %v spots within %v instead of %v.", count, radius, expected)
+		}
+	}
+
+	if spots := mesh.FindHidingSpotsInRadius(Vector3{3e10, -3e10, 0}, 10, 0); len(spots) != 1 || spots[0].ID != 2 {
+		t.Errorf("Found %v near a spot outside of the grid.", spots)
+	}
+}
@@ -19,6 +19,8 @@
 // Package gonav provides functionality related to CS:GO Nav Meshes
 package gonav
 
+import "fmt"
+
 // NavLadderDirection represents the direction between a ladder and its connection
 type NavLadderDirection int
 
@@ -33,6 +35,18 @@ const (
 	NavLadderDirectionMax
 )
 
+// String converts a NavLadderDirection into a human readable string
+func (direction NavLadderDirection) String() string {
+	switch direction {
+	case NavLadderDirectionUp:
+		return "up"
+	case NavLadderDirectionDown:
+		return "down"
+	}
+
+	return fmt.Sprintf("NavLadderDirection(%d)", int(direction))
+}
+
 // NavLadderConnection represents a connection between an area and a ladder
 type NavLadderConnection struct {
 	SourceArea   *NavArea           // The area that is the source of this NavConnection
@@ -61,6 +75,79 @@ type NavLadder struct {
 	BottomArea       *NavArea           // The area connected to the bottom of the ladder
 }
 
+func (ladder *NavLadder) connectGraph(mesh *NavMesh) {
+	ladder.TopForwardArea = mesh.Areas[ladder.TopForwardAreaID]
+	ladder.TopLeftArea = mesh.Areas[ladder.TopLeftAreaID]
+	ladder.TopRightArea = mesh.Areas[ladder.TopRightAreaID]
+	ladder.TopBehindArea = mesh.Areas[ladder.TopBehindAreaID]
+	ladder.BottomArea = mesh.Areas[ladder.BottomAreaID]
+}
+
+// referencesArea determines whether or not this ladder is connected to the area with the specified ID
+func (ladder *NavLadder) referencesArea(id uint32) bool {
+	return ladder.referencesAnyArea(func(currID uint32) bool { return currID == id })
+}
+
+// referencesAnyArea determines whether or not this ladder is connected to any area whose ID is matched.
+// An area ID of 0 means nothing is connected at that position, so it never matches.
+func (ladder *NavLadder) referencesAnyArea(matches func(uint32) bool) bool {
+	for _, currID := range ladder.areaIDs() {
+		if *currID != 0 && matches(*currID) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// removeReferencesToAny detaches this ladder from every area whose ID is removed
+func (ladder *NavLadder) removeReferencesToAny(mesh *NavMesh, removed func(uint32) bool) {
+	for _, currID := range ladder.areaIDs() {
+		if removed(*currID) {
+			*currID = 0
+		}
+	}
+
+	ladder.connectGraph(mesh)
+}
+
+// areaIDs gets pointers to the IDs of the areas at the top and bottom of this ladder
+func (ladder *NavLadder) areaIDs() []*uint32 {
+	return []*uint32{&ladder.TopForwardAreaID, &ladder.TopLeftAreaID, &ladder.TopRightAreaID, &ladder.TopBehindAreaID, &ladder.BottomAreaID}
+}
+
+// ends gets the end of this ladder that is climbed on to and the end that is climbed off of when it is taken in the
+// specified direction
+func (ladder *NavLadder) ends(direction NavLadderDirection) (Vector3, Vector3) {
+	if direction == NavLadderDirectionDown {
+		return ladder.Top, ladder.Bottom
+	}
+
+	return ladder.Bottom, ladder.Top
+}
+
+// GetDestinations gets the areas reached by taking this ladder in the specified direction:
+// the top areas going up and the bottom area going down
+func (ladder *NavLadder) GetDestinations(direction NavLadderDirection) []*NavArea {
+	var candidates []*NavArea
+
+	if direction == NavLadderDirectionUp {
+		candidates = []*NavArea{ladder.TopForwardArea, ladder.TopLeftArea, ladder.TopRightArea, ladder.TopBehindArea}
+	} else if direction == NavLadderDirectionDown {
+		candidates = []*NavArea{ladder.BottomArea}
+	}
+
+	var areas []*NavArea
+
+	for _, currArea := range candidates {
+		if currArea != nil {
+			areas = append(areas, currArea)
+		}
+	}
+
+	return areas
+}
+
 func (conn *NavLadderConnection) connectGraph(mesh *NavMesh) {
 	conn.TargetLadder = mesh.Ladders[conn.TargetID]
 }
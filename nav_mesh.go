@@ -20,39 +20,157 @@
 package gonav
 
 import (
+	"fmt"
 	"math"
+	"runtime"
 	"sync"
 )
 
 // NavMesh represents an entire parsed Nav Mesh and provides functionality
 // related to the manipulation and searching of the mesh
 type NavMesh struct {
-	Places         map[uint32]*NavPlace  // Places contained in this NavMesh
-	Areas          map[uint32]*NavArea   // Areas contained in this NavMesh
-	Ladders        map[uint32]*NavLadder // Ladders contained in this NavMesh
-	QuadTreeAreas  *quadTreeNode         // QuadTree used for quickly searching the NavAreas by position
-	MajorVersion   uint32                // The major version number of the nav file
-	MinorVersion   uint32                // The minor version number of the nav file
-	BSPSize        uint32                // The size of the BSP file the nav was generated from
-	IsMeshAnalyzed bool                  // Tracks whether or not this NavMesh has been analyzed
+	Places          map[uint32]*NavPlace  // Places contained in this NavMesh
+	Areas           map[uint32]*NavArea   // Areas contained in this NavMesh
+	Ladders         map[uint32]*NavLadder // Ladders contained in this NavMesh
+	QuadTreeAreas   *quadTreeNode         // QuadTree used for quickly searching the NavAreas by position
+	UnindexedAreas  []*NavArea            // Areas that could not be added to QuadTreeAreas
+	MajorVersion    uint32                // The major version number of the nav file
+	MinorVersion    uint32                // The minor version number of the nav file
+	BSPSize         uint32                // The size of the BSP file the nav was generated from
+	IsMeshAnalyzed  bool                  // Tracks whether or not this NavMesh has been analyzed
+	HasUnnamedAreas bool                  // Tracks whether or not this NavMesh has areas without a place
+	IsPartial       bool                  // Tracks whether or not parts of the file the parser does not understand were skipped
+	Profile         GameProfile           // The game profile used to decode and encode game-specific data
+	CustomData      interface{}           // Game-specific data for the whole mesh, set by the GameProfile
+
+	areaOrder          []uint32           // IDs of the parsed areas in the order they were parsed
+	ladderOrder        []uint32           // IDs of the parsed ladders in the order they were parsed
+	duplicateAreaIDs   []uint32           // IDs shared by more than one parsed area
+	duplicateLadderIDs []uint32           // IDs shared by more than one parsed ladder
+	indexLock          *sync.Mutex        // Guards the indexes built on first use; see lockIndexes
+	hidingSpots        *hidingSpotIndex   // Index of the hiding spots, built on first use
+	visibility         *visibilityIndex   // Resolved visible sets of the areas, built on first use
+	connectionOptions  *ConnectionOptions // The options of the last RebuildConnections; nil for the defaults
+	batch              *editBatch         // The work deferred by the batch of edits in progress; nil outside of Batch
 }
 
 func (mesh *NavMesh) connectGraph() {
-	var wg sync.WaitGroup
+	for _, ladder := range mesh.Ladders {
+		ladder.connectGraph(mesh)
+	}
+
+	// Encounter spots are resolved against the hiding spots of every area, so index them before the batches start
+	mesh.hidingSpotIndex()
 
+	areas := make([]*NavArea, 0, len(mesh.Areas))
 	for _, area := range mesh.Areas {
+		areas = append(areas, area)
+	}
+
+	forEachAreaBatch(areas, func(area *NavArea) {
+		area.connectGraph(mesh)
+	})
+
+	// Incoming connections are shared between areas, so they're linked once every connection is resolved
+	linkIncoming(areas)
+
+	// Classifying a connection only reads the graph, so it can be batched again
+	stepHeight := mesh.stepHeight()
+	forEachAreaBatch(areas, func(area *NavArea) {
+		for _, currConnection := range area.Connections {
+			currConnection.classify(stepHeight)
+		}
+	})
+}
+
+// forEachAreaBatch calls the specified func for every area, splitting the areas into one batch per CPU
+// rather than one goroutine per area
+func forEachAreaBatch(areas []*NavArea, fn func(*NavArea)) {
+	var wg sync.WaitGroup
+	batchSize := (len(areas) + runtime.GOMAXPROCS(0) - 1) / runtime.GOMAXPROCS(0)
+
+	for start := 0; start < len(areas); start += batchSize {
+		end := start + batchSize
+		if end > len(areas) {
+			end = len(areas)
+		}
+
 		wg.Add(1)
 
-		go func(currArea *NavArea) {
+		go func(batch []*NavArea) {
 			defer wg.Done()
 
-			currArea.connectGraph(mesh)
-		}(area)
+			for _, currArea := range batch {
+				fn(currArea)
+			}
+		}(areas[start:end])
 	}
 
 	wg.Wait()
 }
 
+// indexLockInit guards the creation of the index lock of every mesh. It is only held long enough to create the
+// lock, so building the indexes of one mesh never blocks another.
+var indexLockInit sync.Mutex
+
+// lockIndexes locks the indexes of this mesh that are built on first use, creating the lock if this mesh doesn't
+// have one yet. It returns the func that unlocks them.
+func (mesh *NavMesh) lockIndexes() func() {
+	indexLockInit.Lock()
+	if mesh.indexLock == nil {
+		mesh.indexLock = &sync.Mutex{}
+	}
+
+	lock := mesh.indexLock
+	indexLockInit.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+// invalidateIndexes discards the indexes built on first use so they are rebuilt after an edit
+func (mesh *NavMesh) invalidateIndexes() {
+	mesh.InvalidateHidingSpots()
+	mesh.InvalidateVisibility()
+}
+
+// buildQuadTree builds QuadTreeAreas from scratch, sized to fit every area in the mesh
+func (mesh *NavMesh) buildQuadTree() {
+	areas := sortedAreas(mesh)
+	mesh.QuadTreeAreas = newQuadTree(areas)
+	mesh.UnindexedAreas = nil
+
+	for _, currArea := range areas {
+		if err := mesh.indexArea(currArea); err != nil {
+			mesh.UnindexedAreas = append(mesh.UnindexedAreas, currArea)
+		}
+	}
+}
+
+// indexArea inserts the specified area into QuadTreeAreas, growing the tree if the area is outside of it
+func (mesh *NavMesh) indexArea(area *NavArea) error {
+	if !area.hasFiniteBounds() {
+		return fmt.Errorf("Area %v cannot be indexed because its bounds are not finite.", area.ID)
+	}
+
+	if mesh.QuadTreeAreas == nil {
+		mesh.QuadTreeAreas = newQuadTree([]*NavArea{area})
+	}
+
+	for !mesh.QuadTreeAreas.isAreaFullyContained(area) {
+		root := mesh.QuadTreeAreas.grow(area)
+
+		if !root.hasFiniteBounds() {
+			return fmt.Errorf("Area %v cannot be indexed because it is too far from the other areas.", area.ID)
+		}
+
+		mesh.QuadTreeAreas = root
+	}
+
+	_, err := mesh.QuadTreeAreas.InsertArea(area)
+	return err
+}
+
 // GetPlaceByName gets a NavPlace by the specified name string; nil if not found
 func (mesh *NavMesh) GetPlaceByName(name string) *NavPlace {
 	for _, curr := range mesh.Places {
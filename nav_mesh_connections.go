@@ -0,0 +1,156 @@
+/*
+	gonav - A Source Engine navigation mesh file parser written in Go.
+	Copyright (C) 2016  Matt Razza
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as published
+	by the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package gonav provides functionality related to CS:GO Nav Meshes
+package gonav
+
+import (
+	"math"
+	"sort"
+)
+
+// ConnectionOptions controls which areas NavMesh.RebuildConnections connects
+type ConnectionOptions struct {
+	StepHeight    float32 // The largest height difference that can be walked up; larger ones can only be dropped down
+	MaxDropHeight float32 // The largest height that can be dropped down; areas further apart are not connected
+	EdgeTolerance float32 // How far apart, in units, two edges may be and still be considered touching
+}
+
+// DefaultConnectionOptions gets the options used by the Source engine for a standing player
+func DefaultConnectionOptions() ConnectionOptions {
+	return ConnectionOptions{
+		StepHeight:    18,
+		MaxDropHeight: 200,
+		EdgeTolerance: 1}
+}
+
+// stepHeight gets the largest height change across a portal that is walked rather than dropped or jumped:
+// the step height of the last RebuildConnections, or the default one
+func (mesh *NavMesh) stepHeight() float32 {
+	if mesh.connectionOptions != nil {
+		return mesh.connectionOptions.StepHeight
+	}
+
+	return DefaultConnectionOptions().StepHeight
+}
+
+// RebuildConnections replaces the connections of every rectangular area with ones computed from the geometry.
+// Two areas are connected when their edges touch and overlap in XY. The connection is made in each direction
+// the height difference at the middle of the shared edge allows: up by at most the step height, and down by at
+// most the larger of that and MaxDropHeight, so drops of more than the step height are one-way. Connections of
+// polygonal areas are left alone. From then on, the step height also decides which connections are drops or jumps.
+func (mesh *NavMesh) RebuildConnections(opts ConnectionOptions) {
+	mesh.connectionOptions = &opts
+
+	if mesh.QuadTreeAreas == nil {
+		mesh.buildQuadTree()
+	}
+
+	dropHeight := float32(math.Max(float64(opts.StepHeight), float64(opts.MaxDropHeight)))
+	tolerance := opts.EdgeTolerance
+
+	for _, currArea := range sortedAreas(mesh) {
+		if currArea.IsPolygon() {
+			continue
+		}
+
+		currArea.Connections = nil
+
+		// Areas that could not be indexed are checked as well, in case they are next to each other
+		northWest := Vector3{currArea.NorthWest.X - tolerance, currArea.NorthWest.Y - tolerance, 0}
+		southEast := Vector3{currArea.SouthEast.X + tolerance, currArea.SouthEast.Y + tolerance, 0}
+		candidates := mesh.QuadTreeAreas.FindAreasInBox(northWest, southEast, nil)
+		candidates = append(candidates, mesh.UnindexedAreas...)
+		sort.Slice(candidates, func(i, j int) bool { return candidates[i].ID < candidates[j].ID })
+
+		for _, currCandidate := range candidates {
+			if currCandidate == currArea || currCandidate.IsPolygon() {
+				continue
+			}
+
+			direction, midpoint, ok := adjacentEdge(currArea, currCandidate, tolerance)
+			if !ok {
+				continue
+			}
+
+			rise := clampedZ(currCandidate, midpoint) - clampedZ(currArea, midpoint)
+
+			if rise <= opts.StepHeight && -rise <= dropHeight {
+				currArea.Connections = append(currArea.Connections, &NavConnection{
+					SourceArea:   currArea,
+					TargetAreaID: currCandidate.ID,
+					TargetArea:   currCandidate,
+					Direction:    direction})
+			}
+		}
+	}
+
+	mesh.IsMeshAnalyzed = false
+	mesh.finishEdit()
+}
+
+// adjacentEdge finds the edge of the first area that touches the second area. It gets the direction of that edge
+// and the middle of the part of it shared by both areas; false if the areas do not touch along an edge.
+func adjacentEdge(area *NavArea, other *NavArea, tolerance float32) (NavDirection, Vector3, bool) {
+	near := func(left, right float32) bool {
+		return float32(math.Abs(float64(left-right))) <= tolerance
+	}
+
+	// The overlap must have some length; touching at a corner is not enough
+	overlap := func(minA, maxA, minB, maxB float32) (float32, bool) {
+		low := float32(math.Max(float64(minA), float64(minB)))
+		high := float32(math.Min(float64(maxA), float64(maxB)))
+		return (low + high) / 2, high-low > tolerance
+	}
+
+	if midY, ok := overlap(area.NorthWest.Y, area.SouthEast.Y, other.NorthWest.Y, other.SouthEast.Y); ok {
+		if near(area.SouthEast.X, other.NorthWest.X) {
+			return NavDirectionEast, Vector3{area.SouthEast.X, midY, 0}, true
+		}
+
+		if near(area.NorthWest.X, other.SouthEast.X) {
+			return NavDirectionWest, Vector3{area.NorthWest.X, midY, 0}, true
+		}
+	}
+
+	if midX, ok := overlap(area.NorthWest.X, area.SouthEast.X, other.NorthWest.X, other.SouthEast.X); ok {
+		if near(area.SouthEast.Y, other.NorthWest.Y) {
+			return NavDirectionSouth, Vector3{midX, area.SouthEast.Y, 0}, true
+		}
+
+		if near(area.NorthWest.Y, other.SouthEast.Y) {
+			return NavDirectionNorth, Vector3{midX, area.NorthWest.Y, 0}, true
+		}
+	}
+
+	return NavDirectionMax, Vector3{}, false
+}
+
+// clampedZ gets the Z of the specified area at the point in it closest to the specified point
+func clampedZ(area *NavArea, point Vector3) float32 {
+	x := float32(math.Max(float64(area.NorthWest.X), math.Min(float64(area.SouthEast.X), float64(point.X))))
+	y := float32(math.Max(float64(area.NorthWest.Y), math.Min(float64(area.SouthEast.Y), float64(point.Y))))
+	z, err := area.GetZ(x, y)
+
+	if err != nil {
+		// Inverted or non-finite bounds; fall back on the average height
+		return (area.NorthWest.Z + area.SouthEast.Z) / 2
+	}
+
+	return z
+}
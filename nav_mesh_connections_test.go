@@ -0,0 +1,115 @@
+/*
+	gonav - A Source Engine navigation mesh file parser written in Go.
+	Copyright (C) 2016  Matt Razza
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as published
+	by the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package gonav provides functionality related to CS:GO Nav Meshes
+package gonav
+
+import "testing"
+
+func TestRebuildConnections(t *testing.T) {
+	mesh := parseTestMesh(t, 3)
+
+	// Start from nothing: no connections and no index
+	for _, currArea := range mesh.Areas {
+		currArea.Connections = nil
+	}
+
+	mesh.QuadTreeAreas = nil
+	mesh.RebuildConnections(DefaultConnectionOptions())
+
+	// Each area of the grid links to the areas next to it, in the direction of the edge they share
+	for _, currArea := range mesh.Areas {
+		x, y := int(currArea.ID-1)%3, int(currArea.ID-1)/3
+		expected := make(map[uint32]NavDirection)
+
+		for direction, offset := range [][2]int{{0, -1}, {1, 0}, {0, 1}, {-1, 0}} {
+			otherX, otherY := x+offset[0], y+offset[1]
+
+			if otherX >= 0 && otherY >= 0 && otherX < 3 && otherY < 3 {
+				expected[uint32(otherY*3+otherX+1)] = NavDirection(direction)
+			}
+		}
+
+		if len(currArea.Connections) != len(expected) {
+			t.Errorf("Area %v has %v connections, expected %v.", currArea.ID, len(currArea.Connections), len(expected))
+		}
+
+		for _, currConnection := range currArea.Connections {
+			if direction, ok := expected[currConnection.TargetAreaID]; !ok || currConnection.Direction != direction {
+				t.Errorf("Area %v connects to area %v to the %v, expected %v.", currArea.ID, currConnection.TargetAreaID, currConnection.Direction, direction)
+			}
+
+			if currConnection.Kind != NavConnectionBidirectional {
+				t.Errorf("Connection from area %v to area %v is %v.", currArea.ID, currConnection.TargetAreaID, currConnection.Kind)
+			}
+		}
+	}
+
+	checkIncoming(t, mesh)
+}
+
+func TestRebuildConnectionsHeights(t *testing.T) {
+	mesh := parseTestMesh(t, 3)
+
+	// Area 2 is a 30 unit drop from its neighbours, which is more than a step but less than the old jump height.
+	// Area 9 is 300 units above its neighbours, too high to drop down from.
+	for _, currCase := range []struct {
+		id     uint32
+		offset float32
+	}{{2, 30}, {9, 300}} {
+		if err := mesh.MoveArea(mesh.Areas[currCase.id], Vector3{0, 0, currCase.offset}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mesh.RebuildConnections(DefaultConnectionOptions())
+
+	for _, currCase := range []struct {
+		from, to  uint32
+		direction NavDirection
+		connected bool
+	}{
+		{2, 1, NavDirectionWest, true},
+		{2, 3, NavDirectionEast, true},
+		{2, 5, NavDirectionSouth, true},
+		{1, 2, NavDirectionEast, false},
+		{3, 2, NavDirectionWest, false},
+		{5, 2, NavDirectionNorth, false},
+		{9, 6, NavDirectionNorth, false},
+		{9, 8, NavDirectionWest, false},
+		{6, 9, NavDirectionSouth, false},
+		{8, 9, NavDirectionEast, false},
+	} {
+		from, to := mesh.Areas[currCase.from], mesh.Areas[currCase.to]
+		connections := from.GetConnectionsTo(to)
+
+		if !currCase.connected {
+			if len(connections) != 0 {
+				t.Errorf("Area %v is connected to area %v.", currCase.from, currCase.to)
+			}
+
+			continue
+		}
+
+		if len(connections) != 1 || connections[0].Direction != currCase.direction || connections[0].Kind != NavConnectionOneWayDrop {
+			t.Errorf("Area %v has connections %v to area %v, expected a one-way drop to the %v.", currCase.from, connections, currCase.to, currCase.direction)
+		}
+	}
+
+	checkIncoming(t, mesh)
+}
@@ -0,0 +1,544 @@
+/*
+	gonav - A Source Engine navigation mesh file parser written in Go.
+	Copyright (C) 2016  Matt Razza
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as published
+	by the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package gonav provides functionality related to CS:GO Nav Meshes
+package gonav
+
+import (
+	"errors"
+	"fmt"
+)
+
+// AddArea adds the specified area to this mesh. If the area's ID is 0 it is given the next unused ID.
+// The area's connections are resolved, any existing references to its ID are resolved to it,
+// it is added to its place and it is indexed by position.
+func (mesh *NavMesh) AddArea(area *NavArea) error {
+	if err := mesh.addArea(area); err != nil {
+		return err
+	}
+
+	mesh.finishEdit()
+	return nil
+}
+
+// addArea does the work of AddArea except for bringing the rest of the mesh up to date, so that edits
+// which add an area as one of their steps can do that once
+func (mesh *NavMesh) addArea(area *NavArea) error {
+	mesh.ensureMaps()
+
+	if area == nil {
+		return errors.New("Cannot add a nil area.")
+	}
+
+	if _, ok := mesh.Areas[area.ID]; ok {
+		return fmt.Errorf("Cannot add area %v because an area with that ID already exists.", area.ID)
+	}
+
+	if err := mesh.checkPlace(area.Place); err != nil {
+		return err
+	}
+
+	if area.ID == 0 {
+		area.ID = mesh.nextAreaID()
+	}
+
+	mesh.noteAreaID(area.ID)
+
+	for _, currConnection := range area.Connections {
+		currConnection.SourceArea = area
+	}
+
+	for _, currConnection := range area.LadderConnections {
+		currConnection.SourceArea = area
+	}
+
+	mesh.Areas[area.ID] = area
+
+	if area.Place != nil {
+		area.Place.Areas = append(area.Place.Areas, area)
+	} else {
+		mesh.HasUnnamedAreas = true
+	}
+
+	if err := mesh.indexArea(area); err != nil {
+		mesh.UnindexedAreas = append(mesh.UnindexedAreas, area)
+	}
+
+	area.connectGraph(mesh)
+
+	if mesh.batch != nil {
+		mesh.batch.addArea(mesh, area.ID)
+	} else {
+		mesh.relinkArea(area.ID)
+	}
+
+	return nil
+}
+
+// RemoveArea removes the specified area from this mesh along with every connection, encounter path,
+// approach area, visible area and area bind that references it. Ladders attached to the area are detached from it.
+func (mesh *NavMesh) RemoveArea(area *NavArea) error {
+	if err := mesh.checkArea(area); err != nil {
+		return err
+	}
+
+	delete(mesh.Areas, area.ID)
+	mesh.unindexArea(area)
+
+	if area.Place != nil {
+		area.Place.removeArea(area)
+	}
+
+	if mesh.batch != nil {
+		mesh.batch.removedAreas[area.ID] = true
+	} else {
+		mesh.removeReferencesToAreas(func(id uint32) bool { return id == area.ID })
+	}
+
+	area.incoming = nil
+	mesh.updateHasUnnamedAreas()
+	mesh.finishEdit()
+	return nil
+}
+
+// MoveArea moves the specified area, its corners and its hiding spots by the specified offset and re-indexes it
+func (mesh *NavMesh) MoveArea(area *NavArea, offset Vector3) error {
+	if err := mesh.checkArea(area); err != nil {
+		return err
+	}
+
+	// The area has to come out of the index before its bounds change or it won't be found
+	mesh.unindexArea(area)
+
+	area.NorthWest.Add(offset)
+	area.SouthEast.Add(offset)
+	area.NorthEastZ += offset.Z
+	area.SouthWestZ += offset.Z
+
+	for i := range area.Corners {
+		area.Corners[i].Add(offset)
+	}
+
+	for _, currSpot := range area.HidingSpots {
+		currSpot.Location.Add(offset)
+	}
+
+	if err := mesh.indexArea(area); err != nil {
+		mesh.UnindexedAreas = append(mesh.UnindexedAreas, area)
+	}
+
+	// Moving the area changes the height differences that classify its connections
+	mesh.finishEdit()
+	return nil
+}
+
+// Connect adds a one-way connection from one area to another in the specified direction.
+// Call it a second time with the areas swapped to connect them both ways.
+func (mesh *NavMesh) Connect(from *NavArea, to *NavArea, direction NavDirection) error {
+	if err := mesh.checkArea(from); err != nil {
+		return err
+	}
+
+	if err := mesh.checkArea(to); err != nil {
+		return err
+	}
+
+	if from == to {
+		return fmt.Errorf("Cannot connect area %v to itself.", from.ID)
+	}
+
+	if direction < 0 || direction >= NavDirectionMax {
+		return fmt.Errorf("Cannot connect area %v to area %v in invalid direction %v.", from.ID, to.ID, direction)
+	}
+
+	if from.hasConnectionTo(to.ID) {
+		return fmt.Errorf("Area %v is already connected to area %v.", from.ID, to.ID)
+	}
+
+	connection := &NavConnection{
+		SourceArea:   from,
+		TargetAreaID: to.ID,
+		TargetArea:   to,
+		Direction:    direction,
+		Edge:         from.closestEdge(to.GetCenter())}
+
+	from.Connections = append(from.Connections, connection)
+	connection.link(mesh.stepHeight())
+	from.relinkEncounterPaths(mesh)
+	return nil
+}
+
+// Disconnect removes the connection from one area to another. The connection back, if any, is left alone.
+func (mesh *NavMesh) Disconnect(from *NavArea, to *NavArea) error {
+	if err := mesh.checkArea(from); err != nil {
+		return err
+	}
+
+	if err := mesh.checkArea(to); err != nil {
+		return err
+	}
+
+	if !from.hasConnectionTo(to.ID) {
+		return fmt.Errorf("Area %v is not connected to area %v.", from.ID, to.ID)
+	}
+
+	removed := from.GetConnectionsTo(to)
+	from.removeConnectionsTo(to.ID)
+
+	stepHeight := mesh.stepHeight()
+	for _, currConnection := range removed {
+		currConnection.unlink(stepHeight)
+	}
+
+	from.relinkEncounterPaths(mesh)
+	return nil
+}
+
+// SetPlace moves the specified area into the specified place; nil removes the area from its place
+func (mesh *NavMesh) SetPlace(area *NavArea, place *NavPlace) error {
+	if err := mesh.checkArea(area); err != nil {
+		return err
+	}
+
+	if err := mesh.checkPlace(place); err != nil {
+		return err
+	}
+
+	if area.Place != nil {
+		area.Place.removeArea(area)
+	}
+
+	area.Place = place
+
+	if place != nil {
+		place.Areas = append(place.Areas, area)
+	}
+
+	mesh.updateHasUnnamedAreas()
+	return nil
+}
+
+// AddLadder adds the specified ladder to this mesh. If the ladder's ID is 0 it is given the next unused ID.
+// The ladder's areas are resolved and each of them is given a connection to the ladder if it doesn't have one:
+// the bottom area connects up the ladder and the top areas connect down it.
+func (mesh *NavMesh) AddLadder(ladder *NavLadder) error {
+	mesh.ensureMaps()
+
+	if ladder == nil {
+		return errors.New("Cannot add a nil ladder.")
+	}
+
+	if _, ok := mesh.Ladders[ladder.ID]; ok {
+		return fmt.Errorf("Cannot add ladder %v because a ladder with that ID already exists.", ladder.ID)
+	}
+
+	// An area ID of 0 means nothing is connected at that position
+	for _, currID := range []uint32{ladder.TopForwardAreaID, ladder.TopLeftAreaID, ladder.TopRightAreaID, ladder.TopBehindAreaID, ladder.BottomAreaID} {
+		if _, ok := mesh.Areas[currID]; currID != 0 && !ok {
+			return fmt.Errorf("Cannot add a ladder connected to area %v because that area does not exist.", currID)
+		}
+	}
+
+	if ladder.ID == 0 {
+		ladder.ID = mesh.nextLadderID()
+	}
+
+	mesh.noteLadderID(ladder.ID)
+	mesh.Ladders[ladder.ID] = ladder
+	ladder.connectGraph(mesh)
+
+	for _, currArea := range []*NavArea{ladder.TopForwardArea, ladder.TopLeftArea, ladder.TopRightArea, ladder.TopBehindArea} {
+		if currArea != nil {
+			currArea.addLadderConnection(ladder, NavLadderDirectionDown)
+		}
+	}
+
+	if ladder.BottomArea != nil {
+		ladder.BottomArea.addLadderConnection(ladder, NavLadderDirectionUp)
+	}
+
+	// Resolve any connections that were waiting on this ladder ID
+	if mesh.batch != nil {
+		mesh.batch.addedLadders[ladder.ID] = true
+	} else {
+		mesh.relinkLadders(func(id uint32) bool { return id == ladder.ID })
+	}
+
+	return nil
+}
+
+// Batch makes the edits done by the specified func as one batch. Each edit normally brings everything derived
+// from the areas up to date, which takes time in proportion to the size of the mesh; inside a batch that work is
+// done once, when the func returns, so bulk edits take time in proportion to the number of edits instead.
+// Until then, the incoming connections, portals, connection kinds, encounter spots, HasUnnamedAreas and
+// references to areas and ladders added or removed in the batch may be out of date.
+// The error returned by the func is returned once the batch is finished. Batches may be nested.
+func (mesh *NavMesh) Batch(edits func() error) error {
+	if mesh.batch != nil {
+		return edits()
+	}
+
+	mesh.batch = newEditBatch()
+	defer mesh.finishBatch()
+
+	return edits()
+}
+
+// editBatch is the work deferred until the end of a Batch
+type editBatch struct {
+	addedAreas   map[uint32]bool // IDs of the areas added, whose references are resolved at the end
+	removedAreas map[uint32]bool // IDs of the areas removed, whose references are removed at the end
+	addedLadders map[uint32]bool // IDs of the ladders added, whose references are resolved at the end
+	lastAreaID   uint32          // The largest area ID in the mesh, once nextAreaID has looked for it
+	lastLadderID uint32          // The largest ladder ID in the mesh, once nextLadderID has looked for it
+	places       bool            // Whether HasUnnamedAreas needs to be recomputed
+	finish       bool            // Whether finishEdit was deferred
+}
+
+func newEditBatch() *editBatch {
+	return &editBatch{
+		addedAreas:   make(map[uint32]bool),
+		removedAreas: make(map[uint32]bool),
+		addedLadders: make(map[uint32]bool)}
+}
+
+// addArea notes that an area was added. An area removed earlier in the batch with the same ID has its
+// references removed first, so they are not resolved to the new area.
+func (batch *editBatch) addArea(mesh *NavMesh, id uint32) {
+	if batch.removedAreas[id] {
+		mesh.removeReferencesToAreas(func(currID uint32) bool { return batch.removedAreas[currID] })
+		batch.removedAreas = make(map[uint32]bool)
+	}
+
+	batch.addedAreas[id] = true
+}
+
+// finishBatch does the work deferred by the batch in progress
+func (mesh *NavMesh) finishBatch() {
+	batch := mesh.batch
+	mesh.batch = nil
+
+	if len(batch.removedAreas) > 0 {
+		mesh.removeReferencesToAreas(func(id uint32) bool { return batch.removedAreas[id] })
+	}
+
+	if len(batch.addedAreas) > 0 {
+		mesh.relinkAreas(func(id uint32) bool { return batch.addedAreas[id] })
+	}
+
+	if len(batch.addedLadders) > 0 {
+		mesh.relinkLadders(func(id uint32) bool { return batch.addedLadders[id] })
+	}
+
+	if batch.places {
+		mesh.updateHasUnnamedAreas()
+	}
+
+	if batch.finish {
+		mesh.finishEdit()
+	}
+}
+
+// finishEdit brings everything derived from the areas of this mesh up to date after an edit: the incoming
+// connections, portals and classifications of the connections, the indexes built on first use and the
+// hiding spots and positions of the encounter spots. Inside a Batch, this is done when the batch ends.
+func (mesh *NavMesh) finishEdit() {
+	if mesh.batch != nil {
+		mesh.batch.finish = true
+		return
+	}
+
+	mesh.relinkIncoming()
+	mesh.invalidateIndexes()
+
+	for _, currArea := range mesh.Areas {
+		currArea.relinkEncounterPaths(mesh)
+	}
+}
+
+// ensureMaps creates the maps of this mesh if they have not been created yet
+func (mesh *NavMesh) ensureMaps() {
+	if mesh.Places == nil {
+		mesh.Places = make(map[uint32]*NavPlace)
+	}
+
+	if mesh.Areas == nil {
+		mesh.Areas = make(map[uint32]*NavArea)
+	}
+
+	if mesh.Ladders == nil {
+		mesh.Ladders = make(map[uint32]*NavLadder)
+	}
+}
+
+// checkArea returns an error if the specified area is not part of this mesh
+func (mesh *NavMesh) checkArea(area *NavArea) error {
+	if area == nil {
+		return errors.New("Area cannot be nil.")
+	}
+
+	if mesh.Areas[area.ID] != area {
+		return fmt.Errorf("Area %v is not part of this mesh.", area.ID)
+	}
+
+	return nil
+}
+
+// checkPlace returns an error if the specified place is not nil and not part of this mesh
+func (mesh *NavMesh) checkPlace(place *NavPlace) error {
+	if place != nil && mesh.Places[place.ID] != place {
+		return fmt.Errorf("Place %v (%v) is not part of this mesh.", place.ID, place.Name)
+	}
+
+	return nil
+}
+
+// nextAreaID gets one more than the largest area ID in this mesh. Inside a Batch, the mesh is only searched once.
+func (mesh *NavMesh) nextAreaID() uint32 {
+	if mesh.batch != nil && mesh.batch.lastAreaID != 0 {
+		return mesh.batch.lastAreaID + 1
+	}
+
+	var id uint32
+
+	for currID := range mesh.Areas {
+		if currID > id {
+			id = currID
+		}
+	}
+
+	if mesh.batch != nil {
+		mesh.batch.lastAreaID = id
+	}
+
+	return id + 1
+}
+
+// noteAreaID keeps the largest area ID known to the Batch in progress, if any, up to date
+func (mesh *NavMesh) noteAreaID(id uint32) {
+	if mesh.batch != nil && mesh.batch.lastAreaID != 0 && id > mesh.batch.lastAreaID {
+		mesh.batch.lastAreaID = id
+	}
+}
+
+// nextLadderID gets one more than the largest ladder ID in this mesh. Inside a Batch, the mesh is only searched once.
+func (mesh *NavMesh) nextLadderID() uint32 {
+	if mesh.batch != nil && mesh.batch.lastLadderID != 0 {
+		return mesh.batch.lastLadderID + 1
+	}
+
+	var id uint32
+
+	for currID := range mesh.Ladders {
+		if currID > id {
+			id = currID
+		}
+	}
+
+	if mesh.batch != nil {
+		mesh.batch.lastLadderID = id
+	}
+
+	return id + 1
+}
+
+// noteLadderID keeps the largest ladder ID known to the Batch in progress, if any, up to date
+func (mesh *NavMesh) noteLadderID(id uint32) {
+	if mesh.batch != nil && mesh.batch.lastLadderID != 0 && id > mesh.batch.lastLadderID {
+		mesh.batch.lastLadderID = id
+	}
+}
+
+// updateHasUnnamedAreas sets HasUnnamedAreas from whether any area of this mesh has no place.
+// Inside a Batch, this is done when the batch ends.
+func (mesh *NavMesh) updateHasUnnamedAreas() {
+	if mesh.batch != nil {
+		mesh.batch.places = true
+		return
+	}
+
+	mesh.HasUnnamedAreas = false
+
+	for _, currArea := range mesh.Areas {
+		if currArea.Place == nil {
+			mesh.HasUnnamedAreas = true
+			return
+		}
+	}
+}
+
+// unindexArea removes the specified area from QuadTreeAreas or UnindexedAreas
+func (mesh *NavMesh) unindexArea(area *NavArea) {
+	for i, currArea := range mesh.UnindexedAreas {
+		if currArea == area {
+			mesh.UnindexedAreas = append(mesh.UnindexedAreas[:i], mesh.UnindexedAreas[i+1:]...)
+			return
+		}
+	}
+
+	if mesh.QuadTreeAreas != nil {
+		mesh.QuadTreeAreas.RemoveArea(area)
+	}
+}
+
+// relinkArea resolves every reference to the specified area ID held by the areas and ladders of this mesh
+func (mesh *NavMesh) relinkArea(id uint32) {
+	mesh.relinkAreas(func(currID uint32) bool { return currID == id })
+}
+
+// relinkAreas resolves every reference to the areas whose IDs are added held by the areas and ladders of this mesh
+func (mesh *NavMesh) relinkAreas(added func(uint32) bool) {
+	for _, currArea := range mesh.Areas {
+		if currArea.referencesAnyArea(added) {
+			currArea.connectGraph(mesh)
+		}
+	}
+
+	for _, currLadder := range mesh.Ladders {
+		if currLadder.referencesAnyArea(added) {
+			currLadder.connectGraph(mesh)
+		}
+	}
+}
+
+// relinkLadders resolves the ladder connections of the areas of this mesh to the ladders whose IDs are added
+func (mesh *NavMesh) relinkLadders(added func(uint32) bool) {
+	for _, currArea := range mesh.Areas {
+		for _, currConnection := range currArea.LadderConnections {
+			if added(currConnection.TargetID) {
+				currConnection.connectGraph(mesh)
+			}
+		}
+	}
+}
+
+// removeReferencesToAreas removes every reference held by the areas and ladders of this mesh to the areas whose
+// IDs are removed
+func (mesh *NavMesh) removeReferencesToAreas(removed func(uint32) bool) {
+	for _, currArea := range mesh.Areas {
+		if currArea.referencesAnyArea(removed) {
+			currArea.removeReferencesToAny(removed)
+		}
+	}
+
+	for _, currLadder := range mesh.Ladders {
+		if currLadder.referencesAnyArea(removed) {
+			currLadder.removeReferencesToAny(mesh, removed)
+		}
+	}
+}
@@ -0,0 +1,346 @@
+/*
+	gonav - A Source Engine navigation mesh file parser written in Go.
+	Copyright (C) 2016  Matt Razza
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as published
+	by the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package gonav provides functionality related to CS:GO Nav Meshes
+package gonav
+
+import (
+	"bytes"
+	"testing"
+)
+
+// parseTestMesh writes and parses a size by size test mesh, so it is connected the same way a parsed file is
+func parseTestMesh(tb testing.TB, size int) *NavMesh {
+	parser := Parser{Reader: bytes.NewReader(writeTestMesh(tb, newTestMesh(size), 16))}
+	mesh, err := parser.Parse()
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return &mesh
+}
+
+// checkIncoming fails the test if the incoming connections of any area of the mesh are not exactly
+// the connections that target it
+func checkIncoming(tb testing.TB, mesh *NavMesh) {
+	expected := make(map[*NavArea]int)
+
+	for _, currArea := range mesh.Areas {
+		for _, currConnection := range currArea.Connections {
+			if currConnection.TargetArea != mesh.Areas[currConnection.TargetAreaID] {
+				tb.Errorf("Connection from area %v to area %v is not resolved.", currArea.ID, currConnection.TargetAreaID)
+			}
+
+			if currConnection.TargetArea != nil {
+				expected[currConnection.TargetArea]++
+			}
+		}
+	}
+
+	for _, currArea := range mesh.Areas {
+		if len(currArea.IncomingConnections()) != expected[currArea] {
+			tb.Errorf("Area %v has %v incoming connections, expected %v.", currArea.ID, len(currArea.IncomingConnections()), expected[currArea])
+		}
+	}
+}
+
+func TestAddArea(t *testing.T) {
+	mesh := parseTestMesh(t, 3)
+
+	// Area 3 already refers to the area about to be added
+	east := mesh.Areas[3]
+	east.Connections = append(east.Connections, &NavConnection{SourceArea: east, TargetAreaID: 10, Direction: NavDirectionEast})
+
+	area := &NavArea{
+		NorthWest:   Vector3{75, 0, 3},
+		SouthEast:   Vector3{100, 25, 3},
+		NorthEastZ:  3,
+		SouthWestZ:  3,
+		Place:       mesh.Places[1],
+		Connections: []*NavConnection{{TargetAreaID: 3, Direction: NavDirectionWest}}}
+
+	if err := mesh.AddArea(area); err != nil {
+		t.Fatal(err)
+	}
+
+	if area.ID != 10 {
+		t.Errorf("Area was given ID %v, expected 10.", area.ID)
+	}
+
+	if found := mesh.QuadTreeAreas.FindAreaByPoint(Vector3{90, 10, 3}, true); found != area {
+		t.Errorf("Found %v at the new area's position.", found)
+	}
+
+	if !area.IsConnectedTo(east) || !east.IsConnectedTo(area) || area.Connections[0].Kind != NavConnectionBidirectional {
+		t.Error("The new area is not connected both ways to area 3.")
+	}
+
+	if places := mesh.Places[1].Areas; places[len(places)-1] != area {
+		t.Error("The new area was not added to its place.")
+	}
+
+	checkIncoming(t, mesh)
+
+	if err := mesh.AddArea(&NavArea{ID: 10}); err == nil {
+		t.Error("Added a second area with ID 10.")
+	}
+}
+
+func TestRemoveArea(t *testing.T) {
+	mesh := parseTestMesh(t, 3)
+	center := mesh.Areas[5]
+
+	if err := mesh.RemoveArea(center); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, currArea := range mesh.Areas {
+		if currArea.referencesArea(5) {
+			t.Errorf("Area %v still references the removed area.", currArea.ID)
+		}
+	}
+
+	if mesh.QuadTreeAreas.FindAreaByPoint(center.GetCenter(), true) != nil {
+		t.Error("The removed area is still indexed.")
+	}
+
+	for _, currArea := range center.Place.Areas {
+		if currArea == center {
+			t.Error("The removed area is still in its place.")
+		}
+	}
+
+	// The ladder comes down to area 1
+	if err := mesh.RemoveArea(mesh.Areas[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	if ladder := mesh.Ladders[1]; ladder.BottomAreaID != 0 || ladder.BottomArea != nil {
+		t.Error("The ladder is still attached to the removed area.")
+	}
+
+	checkIncoming(t, mesh)
+
+	if err := mesh.RemoveArea(center); err == nil {
+		t.Error("Removed an area that is no longer part of the mesh.")
+	}
+}
+
+func TestMoveArea(t *testing.T) {
+	mesh := parseTestMesh(t, 3)
+	area := mesh.Areas[9]
+
+	if err := mesh.MoveArea(area, Vector3{100, 0, 50}); err != nil {
+		t.Fatal(err)
+	}
+
+	if found := mesh.QuadTreeAreas.FindAreaByPoint(Vector3{165, 65, 0}, true); found != area {
+		t.Errorf("Found %v at the moved area's new position.", found)
+	}
+
+	if area.NorthWest.Z != 52 || area.NorthEastZ != 53 {
+		t.Errorf("Area heights were not moved: %v %v.", area.NorthWest, area.NorthEastZ)
+	}
+}
+
+func TestConnectAndDisconnect(t *testing.T) {
+	mesh := parseTestMesh(t, 3)
+	from, to := mesh.Areas[1], mesh.Areas[9]
+
+	if err := mesh.Connect(from, to, NavDirectionSouth); err != nil {
+		t.Fatal(err)
+	}
+
+	connections := from.GetConnectionsTo(to)
+	if len(connections) != 1 || connections[0].Kind == NavConnectionBidirectional {
+		t.Errorf("Expected one one-way connection from area 1 to area 9, got %v.", connections)
+	}
+
+	checkIncoming(t, mesh)
+
+	for _, test := range []struct {
+		from, to  *NavArea
+		direction NavDirection
+	}{
+		{from, from, NavDirectionNorth},
+		{from, to, NavDirectionSouth},
+		{to, from, NavDirectionMax},
+	} {
+		if err := mesh.Connect(test.from, test.to, test.direction); err == nil {
+			t.Errorf("Connected area %v to area %v in direction %v.", test.from.ID, test.to.ID, test.direction)
+		}
+	}
+
+	// Areas 1 and 2 are connected both ways; disconnecting one way leaves the other one-way
+	if err := mesh.Disconnect(mesh.Areas[2], from); err != nil {
+		t.Fatal(err)
+	}
+
+	if back := from.GetConnectionsTo(mesh.Areas[2]); len(back) != 1 || back[0].Kind == NavConnectionBidirectional {
+		t.Errorf("Expected the connection back to become one-way, got %v.", back)
+	}
+
+	checkIncoming(t, mesh)
+
+	if err := mesh.Disconnect(mesh.Areas[2], from); err == nil {
+		t.Error("Disconnected areas that were not connected.")
+	}
+}
+
+func TestSetPlace(t *testing.T) {
+	mesh := parseTestMesh(t, 3)
+	place := mesh.Places[1]
+
+	// Areas 1, 6 and 8 have no place
+	for _, currID := range []uint32{1, 6, 8} {
+		if !mesh.HasUnnamedAreas {
+			t.Fatalf("HasUnnamedAreas was cleared before area %v was given a place.", currID)
+		}
+
+		if err := mesh.SetPlace(mesh.Areas[currID], place); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if mesh.HasUnnamedAreas {
+		t.Error("HasUnnamedAreas is still set once every area has a place.")
+	}
+
+	if err := mesh.SetPlace(mesh.Areas[2], nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if !mesh.HasUnnamedAreas {
+		t.Error("HasUnnamedAreas was not set when an area lost its place.")
+	}
+
+	if err := mesh.SetPlace(mesh.Areas[2], &NavPlace{ID: 1, Name: "Elsewhere"}); err == nil {
+		t.Error("Set a place that is not part of the mesh.")
+	}
+}
+
+func TestAddLadder(t *testing.T) {
+	mesh := parseTestMesh(t, 3)
+	ladder := &NavLadder{Top: Vector3{0, 50, 100}, Length: 100, TopForwardAreaID: 3, BottomAreaID: 7}
+
+	if err := mesh.AddLadder(ladder); err != nil {
+		t.Fatal(err)
+	}
+
+	if ladder.ID != 2 || ladder.TopForwardArea != mesh.Areas[3] || ladder.BottomArea != mesh.Areas[7] {
+		t.Errorf("Ladder was not added and resolved: %+v", ladder)
+	}
+
+	connection := mesh.Areas[7].LadderConnections[0]
+	if connection.TargetLadder != ladder || connection.Direction != NavLadderDirectionUp {
+		t.Errorf("Bottom area was not connected up the ladder: %+v", connection)
+	}
+
+	if err := mesh.AddLadder(&NavLadder{BottomAreaID: 100}); err == nil {
+		t.Error("Added a ladder connected to an area that does not exist.")
+	}
+}
+
+func TestBatch(t *testing.T) {
+	mesh := parseTestMesh(t, 3)
+
+	// A row of areas east of the mesh, each connected to the next before it is added
+	err := mesh.Batch(func() error {
+		for i := 0; i < 20; i++ {
+			x := float32(75 + i*25)
+			area := &NavArea{
+				NorthWest:   Vector3{x, 0, 3},
+				SouthEast:   Vector3{x + 25, 25, 3},
+				NorthEastZ:  3,
+				SouthWestZ:  3,
+				Connections: []*NavConnection{{TargetAreaID: uint32(11 + i), Direction: NavDirectionEast}}}
+
+			if err := mesh.AddArea(area); err != nil {
+				return err
+			}
+		}
+
+		// Removing an area and adding another with its ID must not resolve references to the old one
+		if err := mesh.RemoveArea(mesh.Areas[15]); err != nil {
+			return err
+		}
+
+		if err := mesh.AddArea(&NavArea{ID: 15, NorthWest: Vector3{0, 100, 0}, SouthEast: Vector3{25, 125, 0}}); err != nil {
+			return err
+		}
+
+		return mesh.Connect(mesh.Areas[3], mesh.Areas[10], NavDirectionEast)
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if mesh.batch != nil {
+		t.Fatal("The batch was not finished.")
+	}
+
+	if connections := mesh.Areas[14].Connections; len(connections) != 0 {
+		t.Errorf("Area 14 still connects to the area that was removed: %v", connections)
+	}
+
+	if connections := mesh.Areas[10].Connections; len(connections) != 1 || connections[0].TargetArea != mesh.Areas[11] {
+		t.Errorf("Area 10 was not connected to area 11 when the batch finished: %v", connections)
+	}
+
+	if mesh.Areas[29].Connections[0].TargetArea != nil {
+		t.Error("The last area is connected to an area that was never added.")
+	}
+
+	checkIncoming(t, mesh)
+}
+
+// BenchmarkAddAreas adds a row of 1,000 areas to a 2,500 area mesh, one edit at a time or as a single Batch
+func BenchmarkAddAreas(b *testing.B) {
+	for _, batched := range []bool{false, true} {
+		name := "Single"
+		if batched {
+			name = "Batch"
+		}
+
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				mesh := parseTestMesh(b, 50)
+				b.StartTimer()
+
+				add := func() error {
+					for j := 0; j < 1000; j++ {
+						x := float32(1250 + j*25)
+						if err := mesh.AddArea(&NavArea{NorthWest: Vector3{x, 0, 0}, SouthEast: Vector3{x + 25, 25, 0}}); err != nil {
+							return err
+						}
+					}
+
+					return nil
+				}
+
+				if batched {
+					mesh.Batch(add)
+				} else {
+					add()
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+/*
+	gonav - A Source Engine navigation mesh file parser written in Go.
+	Copyright (C) 2016  Matt Razza
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as published
+	by the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package gonav provides functionality related to CS:GO Nav Meshes
+package gonav
+
+import (
+	"errors"
+	"math"
+)
+
+// PathOptions controls how FindPath snaps points to the mesh and weighs the paths between them
+type PathOptions struct {
+	AllowBelow         bool                     // Whether a point may snap to an area above it, as with GetNearestArea
+	AttributePenalties AttributePenalties       // Multipliers for the distance walked through areas with these attributes; nil for none
+	ConnectionCost     MeshConnectionCalculator // Extra cost added to every connection; nil for none, +Inf forbids the connection
+	AvoidLadders       bool                     // Whether ladders are never used
+}
+
+// DefaultPathOptions gets options that penalize areas by DefaultAttributePenalties and allow ladders. AllowBelow is
+// false, so FindPath never snaps a point to an area above it: a point under a floor snaps to the floor below it, and a
+// point below every area is an error.
+func DefaultPathOptions() PathOptions {
+	return PathOptions{AttributePenalties: DefaultAttributePenalties()}
+}
+
+// FindPath finds the shortest path between two points. Both points are snapped to the mesh: to the area containing
+// them or, failing that, the nearest area. The path is costed by the distance actually walked between the points the
+// path enters each area at, rather than between area centers. It returns the path between the areas along with its
+// smoothed waypoints, which start and end at the snapped points.
+func (mesh *NavMesh) FindPath(from, to Vector3, opts PathOptions) (Path, []Vector3, error) {
+	startArea, start := mesh.snapToMesh(from, opts.AllowBelow)
+	if startArea == nil {
+		return Path{}, nil, errors.New("Could not find an area near the start point.")
+	}
+
+	endArea, end := mesh.snapToMesh(to, opts.AllowBelow)
+	if endArea == nil {
+		return Path{}, nil, errors.New("Could not find an area near the end point.")
+	}
+
+	penalties := opts.AttributePenalties.sortedAttributes()
+
+	// The distance from where an area is entered to the end point is added once the path reaches the end area
+	distance := func(from, to Vector3) float32 {
+		to.Sub(from)
+		return to.Length()
+	}
+
+	remaining := func(area *NavArea, entry Vector3) float32 {
+		if area != endArea {
+			return 0
+		}
+
+		return distance(entry, end) * opts.AttributePenalties.multiplier(area, penalties)
+	}
+
+	path, err := buildShortestPath(startArea, endArea, start, pathCosts{
+		entries: true,
+		connection: func(node *PathNode, con *NavConnection, entry Vector3) float32 {
+			cost := distance(node.Entry, entry) * opts.AttributePenalties.multiplier(node.Area, penalties)
+
+			if opts.ConnectionCost != nil {
+				cost += opts.ConnectionCost(con)
+			}
+
+			return cost + remaining(con.TargetArea, entry)
+		},
+		ladder: func(node *PathNode, ladder *NavLadder, direction NavLadderDirection, area *NavArea, entry Vector3) float32 {
+			if opts.AvoidLadders {
+				return float32(math.Inf(1))
+			}
+
+			climbOn, climbOff := ladder.ends(direction)
+			walk := distance(node.Entry, climbOn) * opts.AttributePenalties.multiplier(node.Area, penalties)
+			return walk + ladder.Length + distance(climbOff, entry) + remaining(area, entry)
+		},
+		heuristic: func(area *NavArea, entry Vector3) float32 {
+			if area == endArea {
+				return 0
+			}
+
+			return distance(entry, end)
+		}})
+
+	if err != nil {
+		return Path{}, nil, err
+	}
+
+	if len(path.Nodes) == 1 {
+		path.Nodes[0].CostFromStart = remaining(startArea, start)
+	}
+
+	return path, path.Smooth(start, end), nil
+}
+
+// snapToMesh gets the area containing the specified point, or the nearest area if none does, and the closest point
+// to it within that area; a nil area if the mesh has no areas
+func (mesh *NavMesh) snapToMesh(point Vector3, allowBelow bool) (*NavArea, Vector3) {
+	var area *NavArea
+
+	if mesh.QuadTreeAreas != nil {
+		area = mesh.QuadTreeAreas.FindAreaByPoint(point, allowBelow)
+	}
+
+	if area == nil {
+		area = mesh.GetNearestArea(point, allowBelow)
+	}
+
+	if area == nil {
+		return nil, point
+	}
+
+	return area, area.GetClosestPointInArea(point)
+}
@@ -0,0 +1,67 @@
+/*
+	gonav - A Source Engine navigation mesh file parser written in Go.
+	Copyright (C) 2016  Matt Razza
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as published
+	by the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package gonav provides functionality related to CS:GO Nav Meshes
+package gonav
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFindPathPenalizesTheAreaWalked(t *testing.T) {
+	mesh := parseTestMesh(t, 3)
+
+	// Walking through area 1 costs the distance and walking through area 2 costs ten times that
+	mesh.Areas[1].Flags = 0
+	mesh.Areas[2].Flags = uint32(NavAttributeAvoid)
+
+	first, second := mesh.Areas[1].GetCenter(), mesh.Areas[2].GetCenter()
+	first.Z += 10
+	second.Z += 10
+
+	distance := func(from, to Vector3) float64 {
+		to.Sub(from)
+		return float64(to.Length())
+	}
+
+	for _, currCase := range []struct {
+		from, to               Vector3
+		fromPenalty, toPenalty float64
+	}{
+		{first, second, 1, 10},
+		{second, first, 10, 1},
+	} {
+		path, _, err := mesh.FindPath(currCase.from, currCase.to, DefaultPathOptions())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(path.Nodes) != 2 {
+			t.Fatalf("Path has %v nodes, expected 2.", len(path.Nodes))
+		}
+
+		start, entry := path.Nodes[0].Entry, path.Nodes[1].Entry
+		end := path.Nodes[1].Area.GetClosestPointInArea(currCase.to)
+		expected := distance(start, entry)*currCase.fromPenalty + distance(entry, end)*currCase.toPenalty
+
+		if cost := float64(path.Nodes[1].CostFromStart); math.Abs(cost-expected) > 1e-3 {
+			t.Errorf("Path from area %v to area %v costs %v, expected %v.", path.Nodes[0].Area.ID, path.Nodes[1].Area.ID, cost, expected)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+/*
+	gonav - A Source Engine navigation mesh file parser written in Go.
+	Copyright (C) 2016  Matt Razza
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as published
+	by the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package gonav provides functionality related to CS:GO Nav Meshes
+package gonav
+
+import (
+	"math"
+	"reflect"
+)
+
+// SimplifyOptions controls which areas NavMesh.Simplify merges
+type SimplifyOptions struct {
+	HeightTolerance float32 // How far, in units, any corner of two areas may be from the surface of the merged area
+	MaxSize         float32 // The largest width or height a merged area may have; 0 means unlimited
+}
+
+// DefaultSimplifyOptions gets options that only merge areas lying on the same plane
+func DefaultSimplifyOptions() SimplifyOptions {
+	return SimplifyOptions{
+		HeightTolerance: 1,
+		MaxSize:         0}
+}
+
+// Simplify greedily merges neighbouring rectangular areas that are connected both ways, share a full edge and have
+// the same flags, place and game-specific data, as long as the merged area stays within the height tolerance of
+// every corner it replaces. Areas are merged along the X axis first, then along the Y axis, until nothing else can
+// be merged. Areas with NavAttributeNoMerge are never merged. Connections, encounter paths, approach areas,
+// visibility entries and area binds that referenced a merged area are pointed at the area that now covers it.
+// It returns a map from every area ID in the mesh before simplifying to the ID of the area that now covers it.
+func (mesh *NavMesh) Simplify(opts SimplifyOptions) map[uint32]uint32 {
+	mapping := make(map[uint32]uint32, len(mesh.Areas))
+	for currID := range mesh.Areas {
+		mapping[currID] = currID
+	}
+
+	merged := make(map[uint32]uint32)
+
+	for changed := true; changed; {
+		changed = false
+
+		for _, axis := range []NavAxis{NavAxisX, NavAxisY} {
+			for _, currArea := range sortedAreas(mesh) {
+				if mesh.Areas[currArea.ID] != currArea {
+					continue // Already merged into another area
+				}
+
+				for mesh.simplifyArea(currArea, axis, opts, merged) {
+					changed = true
+				}
+			}
+		}
+	}
+
+	// Areas merged into an area that was merged in turn are now covered by the last one
+	for currID := range merged {
+		survivor := currID
+		for next, ok := merged[survivor]; ok; next, ok = merged[survivor] {
+			survivor = next
+		}
+
+		merged[currID] = survivor
+	}
+
+	for oldID := range mapping {
+		if survivor, ok := merged[oldID]; ok {
+			mapping[oldID] = survivor
+		}
+	}
+
+	// The merges only moved connections, so the rest of the references are pointed at the merged areas once at the end
+	if len(merged) > 0 {
+		for _, currArea := range mesh.Areas {
+			currArea.remapReferences(merged)
+		}
+
+		mesh.finishEdit()
+	}
+
+	return mapping
+}
+
+// simplifyArea merges the first suitable neighbour on the specified axis into the specified area; false if there was none
+func (mesh *NavMesh) simplifyArea(area *NavArea, axis NavAxis, opts SimplifyOptions, merged map[uint32]uint32) bool {
+	for _, currConnection := range area.Connections {
+		other := currConnection.TargetArea
+
+		if other == nil || !canSimplify(area, other, axis, opts) {
+			continue
+		}
+
+		if err := mesh.mergeAreas(area, other); err != nil {
+			continue
+		}
+
+		merged[other.ID] = area.ID
+		return true
+	}
+
+	return false
+}
+
+// canSimplify determines whether or not the specified areas can be merged by Simplify along the specified axis
+func canSimplify(a *NavArea, b *NavArea, axis NavAxis, opts SimplifyOptions) bool {
+	if a.IsPolygon() || b.IsPolygon() {
+		return false
+	}
+
+	if a.Flags != b.Flags || a.HasAttribute(NavAttributeNoMerge) || a.Place != b.Place || !reflect.DeepEqual(a.CustomData, b.CustomData) {
+		return false
+	}
+
+	if !a.hasConnectionTo(b.ID) || !b.hasConnectionTo(a.ID) {
+		return false
+	}
+
+	west, east := a, b
+	if !sharesEdge(a, b, axis) {
+		if !sharesEdge(b, a, axis) {
+			return false
+		}
+
+		west, east = b, a
+	}
+
+	// Build the area the merge would produce and make sure it still describes every corner
+	combined := NavArea{
+		NorthWest:  west.NorthWest,
+		SouthEast:  east.SouthEast,
+		NorthEastZ: east.NorthEastZ,
+		SouthWestZ: west.SouthWestZ}
+
+	if axis == NavAxisY {
+		combined.NorthEastZ = west.NorthEastZ
+		combined.SouthWestZ = east.SouthWestZ
+	}
+
+	width := combined.SouthEast.X - combined.NorthWest.X
+	height := combined.SouthEast.Y - combined.NorthWest.Y
+
+	if opts.MaxSize > 0 && (width > opts.MaxSize || height > opts.MaxSize) {
+		return false
+	}
+
+	for _, currArea := range []*NavArea{a, b} {
+		for _, currCorner := range []Vector3{currArea.NorthWest, currArea.GetNorthEastPoint(), currArea.GetSouthWestPoint(), currArea.SouthEast} {
+			z, err := combined.GetZ(currCorner.X, currCorner.Y)
+
+			if err != nil || float32(math.Abs(float64(z-currCorner.Z))) > opts.HeightTolerance {
+				return false
+			}
+		}
+	}
+
+	return true
+}
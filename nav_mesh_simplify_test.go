@@ -0,0 +1,82 @@
+/*
+	gonav - A Source Engine navigation mesh file parser written in Go.
+	Copyright (C) 2016  Matt Razza
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as published
+	by the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package gonav provides functionality related to CS:GO Nav Meshes
+package gonav
+
+import "testing"
+
+func TestSimplifyRemapsReferences(t *testing.T) {
+	mesh := parseTestMesh(t, 3)
+
+	// Areas 1 and 4 are the only neighbours in the same place, so they are the only ones merged
+	if err := mesh.SetPlace(mesh.Areas[4], nil); err != nil {
+		t.Fatal(err)
+	}
+
+	corner := mesh.Areas[9]
+	corner.InheritVisibilityFromAreaID = 4
+	corner.VisibleAreas = append(corner.VisibleAreas, &NavVisibleArea{VisibleAreaID: 4, Attributes: NavVisibilityPartiallyVisible})
+
+	mapping := mesh.Simplify(DefaultSimplifyOptions())
+
+	if len(mesh.Areas) != 8 || mapping[4] != 1 || mapping[5] != 5 {
+		t.Fatalf("Simplified to %v areas with area 4 now %v, expected only area 4 to be merged into area 1.", len(mesh.Areas), mapping[4])
+	}
+
+	merged := mesh.Areas[1]
+
+	// Area 5 is crossed from area 4 to area 6
+	if len(mesh.Areas[5].EncounterPaths) != 1 {
+		t.Fatalf("Area 5 has %v encounter paths, expected 1.", len(mesh.Areas[5].EncounterPaths))
+	}
+
+	path := mesh.Areas[5].EncounterPaths[0]
+	if path.FromAreaID != 1 || path.FromArea != merged || path.ToAreaID != 6 {
+		t.Errorf("Encounter path runs from area %v to area %v, expected 1 to 6.", path.FromAreaID, path.ToAreaID)
+	}
+
+	if mesh.Areas[5].GetEncounterSpots(merged, mesh.Areas[6]) == nil {
+		t.Error("No encounter spots crossing area 5 from the merged area.")
+	}
+
+	if corner.InheritVisibilityFromAreaID != 1 {
+		t.Errorf("Area 9 inherits visibility from area %v, expected 1.", corner.InheritVisibilityFromAreaID)
+	}
+
+	// Area 9 could see all of area 1 and part of area 4, so it sees part of the merged area
+	if len(corner.VisibleAreas) != 1 || corner.VisibleAreas[0].VisibleArea != merged || corner.VisibleAreas[0].Attributes != NavVisibilityPartiallyVisible {
+		t.Errorf("Area 9 has visibility entries %v, expected one for part of area 1.", corner.VisibleAreas)
+	}
+
+	if !mesh.IsAreaVisible(corner, merged) {
+		t.Error("The merged area is not visible from area 9.")
+	}
+
+	if len(merged.VisibleAreas) != 1 || merged.VisibleAreas[0].VisibleAreaID != 1 {
+		t.Error("The merged area lost its own visibility entry.")
+	}
+
+	for _, currArea := range mesh.Areas {
+		if currArea.referencesArea(4) {
+			t.Errorf("Area %v still references the merged area.", currArea.ID)
+		}
+	}
+
+	checkIncoming(t, mesh)
+}
@@ -0,0 +1,484 @@
+/*
+	gonav - A Source Engine navigation mesh file parser written in Go.
+	Copyright (C) 2016  Matt Razza
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as published
+	by the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package gonav provides functionality related to CS:GO Nav Meshes
+package gonav
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// NavAxis represents one of the horizontal axes of the world
+type NavAxis int
+
+const (
+	// NavAxisX is the west-east axis; splitting along it divides an area at an X coordinate
+	NavAxisX NavAxis = iota
+
+	// NavAxisY is the north-south axis; splitting along it divides an area at a Y coordinate
+	NavAxisY
+)
+
+// mergeTolerance is how far apart the shared edges of two areas may be and still be merged
+const mergeTolerance float32 = 0.1
+
+// SplitArea splits a rectangular area in two at the specified position on the specified axis, like nav_split.
+// The area keeps its ID and becomes the west (NavAxisX) or north (NavAxisY) half; the other half is a new area.
+// Corner Zs and light intensities are interpolated along the split, the halves are connected to each other,
+// and connections, ladders and hiding spots go to the half they belong to. Visibility, encounter and approach
+// data stays with the original area and the mesh is marked as no longer analyzed.
+func (mesh *NavMesh) SplitArea(area *NavArea, axis NavAxis, position float32) (*NavArea, *NavArea, error) {
+	if err := mesh.checkArea(area); err != nil {
+		return nil, nil, err
+	}
+
+	if area.IsPolygon() {
+		return nil, nil, fmt.Errorf("Cannot split polygonal area %v.", area.ID)
+	}
+
+	if err := mesh.checkPlace(area.Place); err != nil {
+		return nil, nil, err
+	}
+
+	var min, max float32
+
+	switch axis {
+	case NavAxisX:
+		min, max = area.NorthWest.X, area.SouthEast.X
+	case NavAxisY:
+		min, max = area.NorthWest.Y, area.SouthEast.Y
+	default:
+		return nil, nil, fmt.Errorf("Cannot split area %v on invalid axis %v.", area.ID, axis)
+	}
+
+	if !(position > min && position < max) {
+		return nil, nil, fmt.Errorf("Cannot split area %v at %v because it is not between %v and %v.", area.ID, position, min, max)
+	}
+
+	t := (position - min) / (max - min)
+	first := area
+	second := &NavArea{
+		Flags:                        area.Flags,
+		Place:                        area.Place,
+		EarliestOccupyTimeFirstTeam:  area.EarliestOccupyTimeFirstTeam,
+		EarliestOccupyTimeSecondTeam: area.EarliestOccupyTimeSecondTeam,
+		CustomData:                   copyAreaCustomData(area.CustomData)}
+
+	// The area has to come out of the index before its bounds change or it won't be found
+	mesh.unindexArea(first)
+	original := *area
+
+	if axis == NavAxisX {
+		northZ := lerp(original.NorthWest.Z, original.NorthEastZ, t)
+		southZ := lerp(original.SouthWestZ, original.SouthEast.Z, t)
+		northLight := lerp(original.NorthWestLightIntensity, original.NorthEastLightIntensity, t)
+		southLight := lerp(original.SouthWestLightIntensity, original.SouthEastLightIntensity, t)
+
+		first.SouthEast = Vector3{position, original.SouthEast.Y, southZ}
+		first.NorthEastZ = northZ
+		first.NorthEastLightIntensity = northLight
+		first.SouthEastLightIntensity = southLight
+
+		second.NorthWest = Vector3{position, original.NorthWest.Y, northZ}
+		second.SouthEast = original.SouthEast
+		second.NorthEastZ = original.NorthEastZ
+		second.SouthWestZ = southZ
+		second.NorthWestLightIntensity = northLight
+		second.NorthEastLightIntensity = original.NorthEastLightIntensity
+		second.SouthWestLightIntensity = southLight
+		second.SouthEastLightIntensity = original.SouthEastLightIntensity
+	} else {
+		westZ := lerp(original.NorthWest.Z, original.SouthWestZ, t)
+		eastZ := lerp(original.NorthEastZ, original.SouthEast.Z, t)
+		westLight := lerp(original.NorthWestLightIntensity, original.SouthWestLightIntensity, t)
+		eastLight := lerp(original.NorthEastLightIntensity, original.SouthEastLightIntensity, t)
+
+		first.SouthEast = Vector3{original.SouthEast.X, position, eastZ}
+		first.SouthWestZ = westZ
+		first.SouthWestLightIntensity = westLight
+		first.SouthEastLightIntensity = eastLight
+
+		second.NorthWest = Vector3{original.NorthWest.X, position, westZ}
+		second.SouthEast = original.SouthEast
+		second.NorthEastZ = eastZ
+		second.SouthWestZ = original.SouthWestZ
+		second.NorthWestLightIntensity = westLight
+		second.NorthEastLightIntensity = eastLight
+		second.SouthWestLightIntensity = original.SouthWestLightIntensity
+		second.SouthEastLightIntensity = original.SouthEastLightIntensity
+	}
+
+	inSecond := func(point Vector3) bool {
+		if axis == NavAxisX {
+			return point.X >= position
+		}
+
+		return point.Y >= position
+	}
+
+	// Hiding spots go to the half they are in
+	spots := first.HidingSpots
+	first.HidingSpots = nil
+
+	for _, currSpot := range spots {
+		if inSecond(currSpot.Location) {
+			second.HidingSpots = append(second.HidingSpots, currSpot)
+		} else {
+			first.HidingSpots = append(first.HidingSpots, currSpot)
+		}
+	}
+
+	// Outgoing connections go to each half that borders the target
+	connections := first.Connections
+	first.Connections = nil
+
+	for _, currConnection := range connections {
+		toFirst, toSecond := splitSides(&original, axis, position, currConnection.Direction, currConnection.TargetArea)
+
+		if toFirst {
+			first.Connections = append(first.Connections, currConnection)
+		}
+
+		if toSecond {
+			copied := *currConnection
+			second.Connections = append(second.Connections, &copied)
+		}
+	}
+
+	// Ladder connections go to the half the end of the ladder is in
+	ladderConnections := first.LadderConnections
+	first.LadderConnections = nil
+
+	for _, currConnection := range ladderConnections {
+		if currConnection.TargetLadder != nil && inSecond(currConnection.ladderEnd()) {
+			second.LadderConnections = append(second.LadderConnections, currConnection)
+		} else {
+			first.LadderConnections = append(first.LadderConnections, currConnection)
+		}
+	}
+
+	forward, backward := NavDirectionEast, NavDirectionWest
+	if axis == NavAxisY {
+		forward, backward = NavDirectionSouth, NavDirectionNorth
+	}
+
+	second.Connections = append(second.Connections, &NavConnection{TargetAreaID: first.ID, Direction: backward})
+
+	if err := mesh.addArea(second); err != nil {
+		// Can't happen; the new area gets an unused ID and the original's place was checked above
+		panic(err)
+	}
+
+	first.Connections = append(first.Connections, &NavConnection{SourceArea: first, TargetAreaID: second.ID, TargetArea: second, Direction: forward})
+
+	if err := mesh.indexArea(first); err != nil {
+		mesh.UnindexedAreas = append(mesh.UnindexedAreas, first)
+	}
+
+	// Incoming connections are redistributed the same way as outgoing ones
+	for _, currArea := range mesh.Areas {
+		if currArea == first || currArea == second {
+			continue
+		}
+
+		for _, currConnection := range currArea.Connections {
+			if currConnection.TargetAreaID != first.ID {
+				continue
+			}
+
+			toFirst, toSecond := splitSides(&original, axis, position, currConnection.Direction.Opposite(), currArea)
+
+			if toSecond && !currArea.hasConnectionTo(second.ID) {
+				currArea.Connections = append(currArea.Connections, &NavConnection{
+					SourceArea:   currArea,
+					TargetAreaID: second.ID,
+					TargetArea:   second,
+					Direction:    currConnection.Direction})
+			}
+
+			if !toFirst {
+				currArea.removeConnectionsTo(first.ID)
+			}
+
+			break
+		}
+	}
+
+	// Ladders attached to the second half follow it
+	for _, currLadder := range mesh.Ladders {
+		slots := []*uint32{&currLadder.TopForwardAreaID, &currLadder.TopLeftAreaID, &currLadder.TopRightAreaID, &currLadder.TopBehindAreaID}
+
+		for _, currID := range slots {
+			if *currID == first.ID && inSecond(currLadder.Top) {
+				*currID = second.ID
+			}
+		}
+
+		if currLadder.BottomAreaID == first.ID && inSecond(currLadder.Bottom) {
+			currLadder.BottomAreaID = second.ID
+		}
+
+		currLadder.connectGraph(mesh)
+	}
+
+	mesh.IsMeshAnalyzed = false
+	mesh.finishEdit()
+	return first, second, nil
+}
+
+// MergeAreas merges two rectangular areas that share a full edge into one, like nav_merge.
+// The first area keeps its ID and grows to cover both; the second area is removed. Connections,
+// ladders and hiding spots of the second area are moved to the first, everything else that referenced
+// the second area now references the first, and the mesh is marked as no longer analyzed. Attribute
+// flags of the two areas are combined.
+func (mesh *NavMesh) MergeAreas(a *NavArea, b *NavArea) (*NavArea, error) {
+	if err := mesh.mergeAreas(a, b); err != nil {
+		return nil, err
+	}
+
+	merged := map[uint32]uint32{b.ID: a.ID}
+	for _, currArea := range mesh.Areas {
+		currArea.remapReferences(merged)
+	}
+
+	mesh.finishEdit()
+	return a, nil
+}
+
+// mergeAreas does the work of MergeAreas without any of the passes over the whole mesh, so that Simplify can
+// merge many areas and make those passes once. Connections into and out of b are moved to a or dropped, but
+// everything else that references b is left for the caller to remove, as are rebuilding the incoming connections
+// and invalidating the indexes.
+func (mesh *NavMesh) mergeAreas(a *NavArea, b *NavArea) error {
+	if err := mesh.checkArea(a); err != nil {
+		return err
+	}
+
+	if err := mesh.checkArea(b); err != nil {
+		return err
+	}
+
+	if a == b {
+		return fmt.Errorf("Cannot merge area %v with itself.", a.ID)
+	}
+
+	if a.IsPolygon() || b.IsPolygon() {
+		return errors.New("Cannot merge polygonal areas.")
+	}
+
+	// Work out where b is relative to a; west and north are handled by swapping the geometry
+	west, east := a, b
+	var axis NavAxis
+
+	switch {
+	case sharesEdge(a, b, NavAxisX):
+		axis = NavAxisX
+	case sharesEdge(b, a, NavAxisX):
+		axis, west, east = NavAxisX, b, a
+	case sharesEdge(a, b, NavAxisY):
+		axis = NavAxisY
+	case sharesEdge(b, a, NavAxisY):
+		axis, west, east = NavAxisY, b, a
+	default:
+		return fmt.Errorf("Cannot merge areas %v and %v because they do not share a full edge.", a.ID, b.ID)
+	}
+
+	// Incoming connections to b now go to a, unless their area is already connected to a
+	for _, currConnection := range b.incoming {
+		source := currConnection.SourceArea
+
+		if source == a || source == b || currConnection.TargetAreaID != b.ID {
+			continue
+		}
+
+		if source.hasConnectionTo(a.ID) {
+			source.removeConnectionsTo(b.ID)
+			continue
+		}
+
+		currConnection.TargetAreaID = a.ID
+		currConnection.TargetArea = a
+		a.incoming = append(a.incoming, currConnection)
+	}
+
+	b.incoming = nil
+
+	// Outgoing connections from b now come from a
+	a.removeConnectionsTo(b.ID)
+
+	for _, currConnection := range b.Connections {
+		if currConnection.TargetAreaID != a.ID && !a.hasConnectionTo(currConnection.TargetAreaID) {
+			currConnection.SourceArea = a
+			a.Connections = append(a.Connections, currConnection)
+		} else {
+			currConnection.removeIncoming()
+		}
+	}
+
+	b.Connections = nil
+
+	// Ladders attached to b are now attached to a
+	for _, currLadder := range mesh.Ladders {
+		for _, currID := range []*uint32{&currLadder.TopForwardAreaID, &currLadder.TopLeftAreaID, &currLadder.TopRightAreaID, &currLadder.TopBehindAreaID, &currLadder.BottomAreaID} {
+			if *currID == b.ID {
+				*currID = a.ID
+			}
+		}
+	}
+
+	for _, currConnection := range b.LadderConnections {
+		if currConnection.TargetLadder != nil {
+			a.addLadderConnection(currConnection.TargetLadder, currConnection.Direction)
+		}
+	}
+
+	for _, currSpot := range b.HidingSpots {
+		currSpot.Area = a
+		a.HidingSpots = append(a.HidingSpots, currSpot)
+	}
+
+	a.Flags |= b.Flags
+
+	// The area has to come out of the index before its bounds change or it won't be found
+	mesh.unindexArea(a)
+	merged := *west
+	merged.SouthEast = east.SouthEast
+
+	if axis == NavAxisX {
+		merged.NorthEastZ = east.NorthEastZ
+		merged.NorthEastLightIntensity = east.NorthEastLightIntensity
+		merged.SouthEastLightIntensity = east.SouthEastLightIntensity
+	} else {
+		merged.SouthWestZ = east.SouthWestZ
+		merged.SouthWestLightIntensity = east.SouthWestLightIntensity
+		merged.SouthEastLightIntensity = east.SouthEastLightIntensity
+	}
+
+	a.NorthWest = merged.NorthWest
+	a.SouthEast = merged.SouthEast
+	a.NorthEastZ = merged.NorthEastZ
+	a.SouthWestZ = merged.SouthWestZ
+	a.NorthWestLightIntensity = merged.NorthWestLightIntensity
+	a.NorthEastLightIntensity = merged.NorthEastLightIntensity
+	a.SouthWestLightIntensity = merged.SouthWestLightIntensity
+	a.SouthEastLightIntensity = merged.SouthEastLightIntensity
+
+	// Take b out of the mesh; ladders no longer reference it and its connections are gone
+	delete(mesh.Areas, b.ID)
+	mesh.unindexArea(b)
+
+	if b.Place != nil {
+		b.Place.removeArea(b)
+	}
+
+	if err := mesh.indexArea(a); err != nil {
+		mesh.UnindexedAreas = append(mesh.UnindexedAreas, a)
+	}
+
+	for _, currLadder := range mesh.Ladders {
+		if currLadder.referencesArea(a.ID) {
+			currLadder.connectGraph(mesh)
+		}
+	}
+
+	mesh.IsMeshAnalyzed = false
+	return nil
+}
+
+// splitSides determines which halves of an area being split at the specified position border the other area.
+// The direction is the one from the area being split towards the other area.
+func splitSides(area *NavArea, axis NavAxis, position float32, direction NavDirection, other *NavArea) (bool, bool) {
+	acrossSplit, firstDirection := NavDirectionEast, NavDirectionWest
+	if axis == NavAxisY {
+		acrossSplit, firstDirection = NavDirectionSouth, NavDirectionNorth
+	}
+
+	switch {
+	case direction == firstDirection:
+		return true, false
+	case direction == acrossSplit:
+		return false, true
+	case other == nil:
+		return true, false
+	}
+
+	otherMin, otherMax := other.NorthWest.X, other.SouthEast.X
+	if axis == NavAxisY {
+		otherMin, otherMax = other.NorthWest.Y, other.SouthEast.Y
+	}
+
+	toFirst := otherMin < position
+	toSecond := otherMax > position
+
+	if !toFirst && !toSecond {
+		// Degenerate neighbour sitting exactly on the split; give it to both
+		return true, true
+	}
+
+	return toFirst, toSecond
+}
+
+// sharesEdge determines whether or not the east (NavAxisX) or south (NavAxisY) edge of the first area
+// is the same as the west or north edge of the second area
+func sharesEdge(first *NavArea, second *NavArea, axis NavAxis) bool {
+	near := func(left, right float32) bool {
+		return float32(math.Abs(float64(left-right))) <= mergeTolerance
+	}
+
+	if axis == NavAxisX {
+		return near(first.SouthEast.X, second.NorthWest.X) &&
+			near(first.NorthWest.Y, second.NorthWest.Y) && near(first.SouthEast.Y, second.SouthEast.Y)
+	}
+
+	return near(first.SouthEast.Y, second.NorthWest.Y) &&
+		near(first.NorthWest.X, second.NorthWest.X) && near(first.SouthEast.X, second.SouthEast.X)
+}
+
+// ladderEnd gets the end of the ladder the source area of this connection is attached to
+func (conn *NavLadderConnection) ladderEnd() Vector3 {
+	if conn.Direction == NavLadderDirectionUp {
+		return conn.TargetLadder.Bottom
+	}
+
+	return conn.TargetLadder.Top
+}
+
+// copyAreaCustomData copies the game-specific data of an area so a new area does not share it.
+// Data of unknown types is shared.
+func copyAreaCustomData(data interface{}) interface{} {
+	switch typed := data.(type) {
+	case *TF2AreaData:
+		copied := *typed
+		return &copied
+	case *L4D2AreaData:
+		copied := *typed
+		return &copied
+	case *CS2AreaData:
+		copied := *typed
+		return &copied
+	}
+
+	return data
+}
+
+// lerp linearly interpolates between the specified values
+func lerp(from, to, t float32) float32 {
+	return from + (to-from)*t
+}
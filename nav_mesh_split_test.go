@@ -0,0 +1,183 @@
+/*
+	gonav - A Source Engine navigation mesh file parser written in Go.
+	Copyright (C) 2016  Matt Razza
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as published
+	by the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package gonav provides functionality related to CS:GO Nav Meshes
+package gonav
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSplitArea(t *testing.T) {
+	mesh := parseTestMesh(t, 3)
+	original := *mesh.Areas[5]
+
+	first, second, err := mesh.SplitArea(mesh.Areas[5], NavAxisX, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first.ID != 5 || second.ID != 10 || mesh.Areas[10] != second {
+		t.Fatalf("Split into areas %v and %v, expected 5 and 10.", first.ID, second.ID)
+	}
+
+	if first.NorthWest != original.NorthWest || first.SouthEast.X != 30 || first.SouthEast.Y != original.SouthEast.Y {
+		t.Errorf("First half spans %v to %v.", first.NorthWest, first.SouthEast)
+	}
+
+	if second.NorthWest.X != 30 || second.NorthWest.Y != original.NorthWest.Y || second.SouthEast != original.SouthEast {
+		t.Errorf("Second half spans %v to %v.", second.NorthWest, second.SouthEast)
+	}
+
+	// The area rises from 1 in the west to 2 in the east, so the split edge is a fifth of the way up
+	for _, currZ := range []float32{first.NorthEastZ, first.SouthEast.Z, second.NorthWest.Z, second.SouthWestZ} {
+		if math.Abs(float64(currZ-1.2)) > 1e-5 {
+			t.Errorf("Split edge is at Z %v, expected 1.2.", currZ)
+		}
+	}
+
+	if len(first.HidingSpots) != 0 || len(second.HidingSpots) != 1 {
+		t.Errorf("Halves have %v and %v hiding spots, expected the spot at X 30 in the second.", len(first.HidingSpots), len(second.HidingSpots))
+	}
+
+	// West only borders the first half, east only the second and north and south border both
+	for _, currCase := range []struct {
+		area     *NavArea
+		expected []uint32
+	}{
+		{first, []uint32{2, 4, 8, 10}},
+		{second, []uint32{2, 5, 6, 8}},
+	} {
+		for _, currID := range []uint32{2, 4, 5, 6, 8, 10} {
+			connected := currCase.area.hasConnectionTo(currID)
+			expected := false
+
+			for _, currExpected := range currCase.expected {
+				expected = expected || currExpected == currID
+			}
+
+			if connected != expected {
+				t.Errorf("Area %v connected to area %v: %v, expected %v.", currCase.area.ID, currID, connected, expected)
+			}
+
+			if other := mesh.Areas[currID]; connected && !other.IsConnectedTo(currCase.area) {
+				t.Errorf("Area %v is not connected back to area %v.", currID, currCase.area.ID)
+			}
+		}
+	}
+
+	if mesh.Areas[6].IsConnectedTo(first) || mesh.Areas[4].IsConnectedTo(second) {
+		t.Error("An area is still connected to the half it no longer borders.")
+	}
+
+	if mesh.IsMeshAnalyzed {
+		t.Error("The mesh is still marked as analyzed.")
+	}
+
+	if found := mesh.QuadTreeAreas.FindAreaByPoint(Vector3{40, 40, 2}, true); found != second {
+		t.Errorf("Found %v in the second half.", found)
+	}
+
+	checkIncoming(t, mesh)
+}
+
+func TestSplitAreaErrors(t *testing.T) {
+	mesh := parseTestMesh(t, 3)
+	area := mesh.Areas[5]
+
+	for _, currCase := range []struct {
+		name     string
+		axis     NavAxis
+		position float32
+	}{
+		{"on the west edge", NavAxisX, 25},
+		{"on the east edge", NavAxisX, 50},
+		{"outside the area", NavAxisY, 60},
+		{"on an invalid axis", NavAxis(2), 30},
+	} {
+		if _, _, err := mesh.SplitArea(area, currCase.axis, currCase.position); err == nil {
+			t.Errorf("Split the area %v.", currCase.name)
+		}
+	}
+
+	if _, _, err := mesh.SplitArea(&NavArea{ID: 5}, NavAxisX, 30); err == nil {
+		t.Error("Split an area that is not part of the mesh.")
+	}
+
+	if len(mesh.Areas) != 9 || area.SouthEast.X != 50 {
+		t.Error("A failed split changed the mesh.")
+	}
+}
+
+func TestMergeAreas(t *testing.T) {
+	mesh := parseTestMesh(t, 3)
+	original := *mesh.Areas[5]
+
+	first, second, err := mesh.SplitArea(mesh.Areas[5], NavAxisY, 35)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The second half shares only part of its east edge with area 6
+	if _, err := mesh.MergeAreas(second, mesh.Areas[6]); err == nil {
+		t.Error("Merged areas that only share part of an edge.")
+	}
+
+	merged, err := mesh.MergeAreas(second, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if merged != second || mesh.Areas[5] != nil {
+		t.Fatal("The second area was not the one kept.")
+	}
+
+	if merged.NorthWest != original.NorthWest || merged.SouthEast != original.SouthEast ||
+		merged.NorthEastZ != original.NorthEastZ || merged.SouthWestZ != original.SouthWestZ {
+		t.Errorf("Merged area spans %v to %v, expected %v to %v.", merged.NorthWest, merged.SouthEast, original.NorthWest, original.SouthEast)
+	}
+
+	// Every neighbour of the original area is now connected both ways to the merged area
+	for _, currID := range []uint32{2, 4, 6, 8} {
+		if other := mesh.Areas[currID]; !merged.IsConnectedTo(other) || !other.IsConnectedTo(merged) {
+			t.Errorf("Area %v is not connected both ways to the merged area.", currID)
+		}
+	}
+
+	for _, currArea := range mesh.Areas {
+		if currArea.referencesArea(5) {
+			t.Errorf("Area %v still references the removed area.", currArea.ID)
+		}
+	}
+
+	checkIncoming(t, mesh)
+
+	for _, currCase := range []struct {
+		name string
+		a, b *NavArea
+	}{
+		{"areas that only share a corner", mesh.Areas[1], mesh.Areas[9]},
+		{"an area with itself", merged, merged},
+		{"an area that is not part of the mesh", merged, first},
+	} {
+		if _, err := mesh.MergeAreas(currCase.a, currCase.b); err == nil {
+			t.Errorf("Merged %v.", currCase.name)
+		}
+	}
+}
@@ -26,6 +26,18 @@ type NavPlace struct {
 	ID    uint32     // ID of the place
 	Name  string     // The name of the place
 	Areas []*NavArea // Collection of areas in this place
+
+	unterminated bool // Whether the name was stored without a null terminator
+}
+
+// removeArea removes the specified area from the areas in this place
+func (np *NavPlace) removeArea(area *NavArea) {
+	for i, currArea := range np.Areas {
+		if currArea == area {
+			np.Areas = append(np.Areas[:i], np.Areas[i+1:]...)
+			return
+		}
+	}
 }
 
 // GetEstimatedCenter gets a rough estimate of the center of this NavPlace
@@ -0,0 +1,306 @@
+/*
+	gonav - A Source Engine navigation mesh file parser written in Go.
+	Copyright (C) 2016  Matt Razza
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as published
+	by the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package gonav provides functionality related to CS:GO Nav Meshes
+package gonav
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+	"strings"
+)
+
+// NavVisibilityFlags represents how much of an area is visible from another
+type NavVisibilityFlags byte
+
+const (
+	NavVisibilityNotVisible         NavVisibilityFlags = 0x00 // The area is not visible; removes an area inherited from another
+	NavVisibilityPotentiallyVisible NavVisibilityFlags = 0x01 // Some part of the area may be visible
+	NavVisibilityPartiallyVisible   NavVisibilityFlags = 0x02 // Part of the area is visible
+	NavVisibilityCompletelyVisible  NavVisibilityFlags = 0x04 // All of the area is visible
+)
+
+// navVisibilityFlagNames are the names of the flags in bit order, as used by the Source engine
+var navVisibilityFlagNames = []string{"POTENTIALLY_VISIBLE", "PARTIALLY_VISIBLE", "COMPLETELY_VISIBLE"}
+
+// String converts a NavVisibilityFlags into a human readable string such as "POTENTIALLY_VISIBLE|PARTIALLY_VISIBLE"
+func (flags NavVisibilityFlags) String() string {
+	if flags == NavVisibilityNotVisible {
+		return "NOT_VISIBLE"
+	}
+
+	var names []string
+
+	for bit, name := range navVisibilityFlagNames {
+		if flags&(1<<uint(bit)) != 0 {
+			names = append(names, name)
+		}
+	}
+
+	if unknown := flags &^ (1<<uint(len(navVisibilityFlagNames)) - 1); unknown != 0 {
+		names = append(names, fmt.Sprintf("0x%x", byte(unknown)))
+	}
+
+	return strings.Join(names, "|")
+}
+
+// IsVisible determines whether or not any of the visible flags are set
+func (flags NavVisibilityFlags) IsVisible() bool {
+	return flags != NavVisibilityNotVisible
+}
+
+// visibleSet is a compressed bitset of area positions. Only the 64-bit words with a bit set are stored, so a set
+// takes space in proportion to the areas in it rather than to the areas in the mesh. A second bitset marks which
+// words are stored and, with the number of words stored before each of its own words, finds the slot of a word
+// in constant time.
+type visibleSet struct {
+	present []uint64 // Bit i is set if word i of the set is stored
+	ranks   []int32  // The number of words stored before each word of present
+	words   []uint64 // The stored words, in ascending order
+}
+
+// contains determines whether or not the area at the specified position is in this set
+func (set *visibleSet) contains(position int) bool {
+	word := position / 64
+	i := word / 64
+
+	if i >= len(set.present) {
+		return false
+	}
+
+	mask := uint64(1) << uint(word%64)
+	if set.present[i]&mask == 0 {
+		return false
+	}
+
+	slot := int(set.ranks[i]) + bits.OnesCount64(set.present[i]&(mask-1))
+	return set.words[slot]&(1<<uint(position%64)) != 0
+}
+
+// newVisibleSet creates an empty set with room for the specified number of words, the last of which has the
+// specified index
+func newVisibleSet(words int, lastWord int) *visibleSet {
+	if words == 0 {
+		return &visibleSet{}
+	}
+
+	return &visibleSet{
+		present: make([]uint64, lastWord/64+1),
+		ranks:   make([]int32, lastWord/64+1),
+		words:   make([]uint64, 0, words)}
+}
+
+// add stores the specified word of this set; words must be added in ascending order, up to the last word
+// the set was created with
+func (set *visibleSet) add(word int, wordBits uint64) {
+	i := word / 64
+
+	if set.present[i] == 0 {
+		set.ranks[i] = int32(len(set.words))
+	}
+
+	set.present[i] |= 1 << uint(word%64)
+	set.words = append(set.words, wordBits)
+}
+
+// eachWord calls the specified func with the index and bits of every stored word of this set, in ascending order
+func (set *visibleSet) eachWord(fn func(word int, wordBits uint64)) {
+	slot := 0
+
+	for i, currPresent := range set.present {
+		for ; currPresent != 0; currPresent &= currPresent - 1 {
+			fn(i*64+bits.TrailingZeros64(currPresent), set.words[slot])
+			slot++
+		}
+	}
+}
+
+// visibilityIndex holds the resolved visible set of every area in a mesh
+type visibilityIndex struct {
+	areas     []*NavArea       // Every area, ordered by ID; bit i of a set is areas[i]
+	positions map[*NavArea]int // The position of each area in areas
+	sets      []*visibleSet    // The visible set of each area, in the same order as areas
+
+	scratch []uint64 // A dense bitset over every area that sets are built in, all zero between builds
+	touched []bool   // Which words of scratch the set being built has used
+}
+
+// newVisibilityIndex resolves the visible set of every area in the specified mesh
+func newVisibilityIndex(mesh *NavMesh) *visibilityIndex {
+	index := &visibilityIndex{
+		areas:     sortedAreas(mesh),
+		positions: make(map[*NavArea]int, len(mesh.Areas))}
+
+	for i, currArea := range index.areas {
+		index.positions[currArea] = i
+	}
+
+	index.sets = make([]*visibleSet, len(index.areas))
+	index.scratch = make([]uint64, (len(index.areas)+63)/64)
+	index.touched = make([]bool, len(index.scratch))
+	resolving := make([]bool, len(index.areas))
+
+	for i := range index.areas {
+		index.resolve(mesh, i, resolving)
+	}
+
+	index.scratch, index.touched = nil, nil
+	return index
+}
+
+// resolve builds the visible set of the area at the specified position. An area that inherits visibility starts
+// with the resolved set of the area it inherits from, then its own entries add areas or, with
+// NavVisibilityNotVisible, remove them. Inheritance cycles are broken by ignoring the link that closes them.
+// An area without entries of its own shares the set it inherits.
+func (index *visibilityIndex) resolve(mesh *NavMesh, position int, resolving []bool) *visibleSet {
+	if index.sets[position] != nil {
+		return index.sets[position]
+	}
+
+	area := index.areas[position]
+	inherited := &visibleSet{}
+	resolving[position] = true
+
+	if parent, ok := index.positions[mesh.Areas[area.InheritVisibilityFromAreaID]]; ok && area.InheritVisibilityFromAreaID != 0 && !resolving[parent] {
+		inherited = index.resolve(mesh, parent, resolving)
+	}
+
+	resolving[position] = false
+
+	if len(area.VisibleAreas) == 0 {
+		index.sets[position] = inherited
+		return inherited
+	}
+
+	// The inherited set is resolved, so the scratch bitset is free to build this one in
+	var words []int32
+
+	touch := func(word int32) {
+		if !index.touched[word] {
+			index.touched[word] = true
+			words = append(words, word)
+		}
+	}
+
+	inherited.eachWord(func(word int, wordBits uint64) {
+		index.scratch[word] = wordBits
+		touch(int32(word))
+	})
+
+	for _, currVisible := range area.VisibleAreas {
+		visible, ok := index.positions[mesh.Areas[currVisible.VisibleAreaID]]
+		if !ok {
+			continue
+		}
+
+		touch(int32(visible / 64))
+
+		if currVisible.Attributes.IsVisible() {
+			index.scratch[visible/64] |= 1 << uint(visible%64)
+		} else {
+			index.scratch[visible/64] &^= 1 << uint(visible%64)
+		}
+	}
+
+	sort.Slice(words, func(i, j int) bool { return words[i] < words[j] })
+
+	// Size the set exactly, since there is one for every area with visibility data
+	count, lastWord := 0, 0
+	for _, currWord := range words {
+		if index.scratch[currWord] != 0 {
+			count++
+			lastWord = int(currWord)
+		}
+	}
+
+	set := newVisibleSet(count, lastWord)
+
+	for _, currWord := range words {
+		if index.scratch[currWord] != 0 {
+			set.add(int(currWord), index.scratch[currWord])
+		}
+
+		index.scratch[currWord] = 0
+		index.touched[currWord] = false
+	}
+
+	index.sets[position] = set
+	return set
+}
+
+// visibilityIndex gets the visibility index of this mesh, building it if it has not been built since the last edit
+func (mesh *NavMesh) visibilityIndex() *visibilityIndex {
+	defer mesh.lockIndexes()()
+
+	if mesh.visibility == nil {
+		mesh.visibility = newVisibilityIndex(mesh)
+	}
+
+	return mesh.visibility
+}
+
+// InvalidateVisibility discards the resolved visible sets of this mesh. It only needs to be called after changing
+// NavArea.VisibleAreas or NavArea.InheritVisibilityFromAreaID directly rather than through the NavMesh methods.
+func (mesh *NavMesh) InvalidateVisibility() {
+	defer mesh.lockIndexes()()
+
+	mesh.visibility = nil
+}
+
+// IsAreaVisible determines whether or not the second area is potentially visible from the first, taking
+// inherited visibility into account. An area is always visible from itself. The visible sets of every
+// area are resolved the first time this or VisibleSet is called.
+func (mesh *NavMesh) IsAreaVisible(from *NavArea, to *NavArea) bool {
+	if from == to {
+		return from != nil
+	}
+
+	index := mesh.visibilityIndex()
+	fromPosition, ok := index.positions[from]
+	if !ok {
+		return false
+	}
+
+	toPosition, ok := index.positions[to]
+	if !ok {
+		return false
+	}
+
+	return index.sets[fromPosition].contains(toPosition)
+}
+
+// VisibleSet gets the areas potentially visible from the specified area, taking inherited visibility into account,
+// ordered by ID. The area itself is only included if its visibility data lists it.
+func (mesh *NavMesh) VisibleSet(area *NavArea) []*NavArea {
+	index := mesh.visibilityIndex()
+	position, ok := index.positions[area]
+	if !ok {
+		return nil
+	}
+
+	var areas []*NavArea
+
+	index.sets[position].eachWord(func(word int, wordBits uint64) {
+		for ; wordBits != 0; wordBits &= wordBits - 1 {
+			areas = append(areas, index.areas[word*64+bits.TrailingZeros64(wordBits)])
+		}
+	})
+
+	return areas
+}
@@ -0,0 +1,108 @@
+/*
+	gonav - A Source Engine navigation mesh file parser written in Go.
+	Copyright (C) 2016  Matt Razza
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as published
+	by the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package gonav provides functionality related to CS:GO Nav Meshes
+package gonav
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestVisibleSetInheritance(t *testing.T) {
+	mesh := NavMesh{Areas: make(map[uint32]*NavArea)}
+
+	for id := uint32(1); id <= 200; id++ {
+		mesh.Areas[id] = &NavArea{ID: id}
+	}
+
+	// Area 1 sees areas across several words of the set; area 2 inherits them, hides one and adds another
+	for _, currID := range []uint32{3, 70, 150} {
+		mesh.Areas[1].VisibleAreas = append(mesh.Areas[1].VisibleAreas, &NavVisibleArea{VisibleAreaID: currID, Attributes: NavVisibilityCompletelyVisible})
+	}
+
+	mesh.Areas[2].InheritVisibilityFromAreaID = 1
+	mesh.Areas[2].VisibleAreas = []*NavVisibleArea{
+		{VisibleAreaID: 70, Attributes: NavVisibilityNotVisible},
+		{VisibleAreaID: 199, Attributes: NavVisibilityPotentiallyVisible}}
+
+	// Area 4 inherits without entries of its own. Areas 5 and 6 inherit from each other; which link of the
+	// cycle is ignored depends on which is resolved first, so only area 5 is checked.
+	mesh.Areas[4].InheritVisibilityFromAreaID = 2
+	mesh.Areas[5].InheritVisibilityFromAreaID = 6
+	mesh.Areas[6].InheritVisibilityFromAreaID = 5
+	mesh.Areas[5].VisibleAreas = []*NavVisibleArea{{VisibleAreaID: 100, Attributes: NavVisibilityPartiallyVisible}}
+
+	expected := map[uint32][]uint32{1: {3, 70, 150}, 2: {3, 150, 199}, 4: {3, 150, 199}, 5: {100}, 7: nil}
+
+	for id, visibleIDs := range expected {
+		visible := mesh.VisibleSet(mesh.Areas[id])
+
+		if len(visible) != len(visibleIDs) {
+			t.Fatalf("Area %v sees %v areas instead of %v.", id, len(visible), visibleIDs)
+		}
+
+		for i, currArea := range visible {
+			if currArea.ID != visibleIDs[i] || !mesh.IsAreaVisible(mesh.Areas[id], currArea) {
+				t.Fatalf("Area %v sees %v instead of %v.", id, visible, visibleIDs)
+			}
+		}
+	}
+
+	if mesh.IsAreaVisible(mesh.Areas[2], mesh.Areas[70]) {
+		t.Error("Area 70 is still visible from area 2 after being hidden.")
+	}
+}
+
+func TestVisibleSetContains(t *testing.T) {
+	// Positions spread over several words of the presence bitset, with gaps of empty words between them
+	positions := []int{0, 63, 64, 700, 4095, 4096, 4097, 9000, 70000}
+	expected := make(map[int]bool)
+	set := newVisibleSet(7, 70000/64)
+
+	for i := 0; i < len(positions); {
+		word := positions[i] / 64
+		var wordBits uint64
+
+		for ; i < len(positions) && positions[i]/64 == word; i++ {
+			wordBits |= 1 << uint(positions[i]%64)
+			expected[positions[i]] = true
+		}
+
+		set.add(word, wordBits)
+	}
+
+	for position := 0; position < 80000; position++ {
+		if set.contains(position) != expected[position] {
+			t.Errorf("Set contains %v: %v, expected %v.", position, set.contains(position), expected[position])
+		}
+	}
+
+	var found []int
+	set.eachWord(func(word int, wordBits uint64) {
+		for bit := 0; bit < 64; bit++ {
+			if wordBits&(1<<uint(bit)) != 0 {
+				found = append(found, word*64+bit)
+			}
+		}
+	})
+
+	if fmt.Sprint(found) != fmt.Sprint(positions) {
+		t.Errorf("Set holds %v, expected %v.", found, positions)
+	}
+}
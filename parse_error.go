@@ -0,0 +1,101 @@
+/*
+	gonav - A Source Engine navigation mesh file parser written in Go.
+	Copyright (C) 2016  Matt Razza
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as published
+	by the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package gonav provides functionality related to CS:GO Nav Meshes
+package gonav
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrBadMagic is returned when the input does not start with the .nav magic number
+	ErrBadMagic = errors.New("Magic number is incorrect. This is not a .nav file.")
+
+	// ErrUnsupportedVersion is returned when the major version of the input is not supported
+	ErrUnsupportedVersion = errors.New("Major version for this nav mesh is not supported.")
+
+	// ErrNoNavFile is returned when a BSP has no pakfile or its pakfile has no .nav file
+	ErrNoNavFile = errors.New("The BSP does not contain a .nav file.")
+
+	// ErrNoReader is returned when the Parser has no Reader to parse
+	ErrNoReader = errors.New("This parse instance does not have a Reader.")
+)
+
+// ParseSection identifies the section of a .nav file being decoded
+type ParseSection int
+
+const (
+	// ParseSectionHeader is the header containing the magic number and version information
+	ParseSectionHeader ParseSection = iota
+
+	// ParseSectionPlaces is the list of place names
+	ParseSectionPlaces
+
+	// ParseSectionAreas is the list of areas
+	ParseSectionAreas
+
+	// ParseSectionLadders is the list of ladders
+	ParseSectionLadders
+)
+
+// String converts a ParseSection into a human readable string
+func (section ParseSection) String() string {
+	switch section {
+	case ParseSectionHeader:
+		return "header"
+	case ParseSectionPlaces:
+		return "places"
+	case ParseSectionAreas:
+		return "areas"
+	case ParseSectionLadders:
+		return "ladders"
+	}
+
+	return fmt.Sprintf("ParseSection(%d)", int(section))
+}
+
+// ParseError describes where and why parsing a .nav file failed
+type ParseError struct {
+	Offset    int64        // Byte offset of the value that could not be decoded
+	Section   ParseSection // The section being decoded
+	AreaIndex int          // Index of the area being decoded; -1 outside of the areas section
+	AreaID    uint32       // ID of the area being decoded; 0 if it is not known
+	Message   string       // Description of what failed
+	Err       error        // The underlying cause
+}
+
+// Error converts a ParseError into a human readable string
+func (e *ParseError) Error() string {
+	location := fmt.Sprintf("%v section at byte offset %v", e.Section, e.Offset)
+	if e.AreaIndex >= 0 {
+		location += fmt.Sprintf(" (area index %v, ID %v)", e.AreaIndex, e.AreaID)
+	}
+
+	if e.Err == nil {
+		return fmt.Sprintf("Failed to parse %s: %s.", location, e.Message)
+	}
+
+	return fmt.Sprintf("Failed to parse %s: %s: %v", location, e.Message, e.Err)
+}
+
+// Unwrap gets the underlying cause of this ParseError
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
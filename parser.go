@@ -20,180 +20,229 @@
 package gonav
 
 import (
-	"encoding/binary"
-	"errors"
 	"fmt"
 	"io"
 )
 
 // Parser provides support for parsing .nav files.
+// The Reader is buffered internally, so Parse may consume bytes past the end of the mesh.
 type Parser struct {
-	Reader io.Reader
+	Reader  io.Reader
+	Profile GameProfile   // Decodes the game-specific data; the CSGOProfile is used if nil
+	Options ParserOptions // Limits the resources used while parsing
+
+	input     *decoder     // Buffered cursor over Reader
+	section   ParseSection // The section currently being decoded
+	areaIndex int          // Index of the area currently being decoded; -1 if none
+	areaID    uint32       // ID of the area currently being decoded
 }
 
 type parserError struct {
 	Message string
 	Error   error
+	Offset  int64
 }
 
 // Parse parses the nav mesh reader supplied to this instance.
-func (p *Parser) Parse() (mesh NavMesh, err error) {
+// Errors encountered while decoding are returned as a *ParseError.
+func (p *Parser) Parse() (NavMesh, error) {
+	var builder meshBuilder
+
+	if err := p.ParseStream(&builder); err != nil {
+		return NavMesh{}, err
+	}
+
+	// Ok we're done parsing the file, now it's time to index the areas and connect the graph
+	builder.mesh.buildQuadTree()
+	builder.mesh.connectGraph()
+
+	return *builder.mesh, nil
+}
+
+// ParseStream parses the nav mesh reader supplied to this instance, handing each part of
+// the mesh to the visitor as soon as it is decoded. No NavMesh graph or quadtree is built,
+// so the areas and ladders passed to the visitor are not connected to each other.
+// Errors encountered while decoding, or returned by the visitor, are returned as a *ParseError.
+func (p *Parser) ParseStream(visitor NavVisitor) (err error) {
 	if p.Reader == nil {
-		return buildParseError("This parse instance does not have a Reader.")
+		return &ParseError{Section: ParseSectionHeader, AreaIndex: -1, Message: "No reader", Err: ErrNoReader}
 	}
 
-	// Handle the recover scenerio for panics from our reader functions
+	p.input = newDecoder(p.Reader, p.Options.MaxBytes)
+	p.section = ParseSectionHeader
+	p.areaIndex = -1
+	p.areaID = 0
+
+	// Handle the recover scenerio for panics from our reader functions. Anything else is a bug in the
+	// parser, the visitor or the profile, so it is left to crash rather than be reported as bad input.
 	defer func() {
 		if r := recover(); r != nil {
-			msg, ok := r.(parserError)
-
-			if ok {
-				mesh, err = buildParseError(fmt.Sprintf("Unexpected error when parsing: %s.\nParent error: %v.", msg.Message, msg.Error))
+			if msg, ok := r.(parserError); ok {
+				err = p.buildParseError(msg)
 			} else {
 				panic(r)
 			}
 		}
 	}()
 
+	p.checkContext()
+
 	// Let's check the magic number
-	var magicNumber uint32
-	p.read(&magicNumber)
+	magicNumber := p.readUint32()
 
 	if magicNumber != 0xFEEDFACE {
-		return buildParseError(fmt.Sprintf("Magic number is incorrect (%v vs %v). This is not a .nav file.", magicNumber, 0xFEEDFACE))
+		panic(parserError{fmt.Sprintf("Magic number is incorrect (%v vs %v)", magicNumber, 0xFEEDFACE), ErrBadMagic, 0})
 	}
 
-	// Magic number passed! Time to start building the NavMesh
-	mesh = NavMesh{}
-	p.read(&mesh.MajorVersion)
-
-	// Check the version
-	if mesh.MajorVersion < 6 || mesh.MajorVersion > 16 {
-		return buildParseError("Major version for this nav mesh is invalid.")
+	// Magic number passed! Time to start building the NavMesh.
+	// Only the header and places are kept on this mesh; everything else goes to the visitor.
+	mesh := &NavMesh{}
+	mesh.MajorVersion = p.readUint32()
+
+	// Check the version; CS2 meshes are made of polygons and laid out differently
+	if mesh.MajorVersion >= minPolygonMajorVersion && mesh.MajorVersion <= maxPolygonMajorVersion {
+		p.parsePolygonMesh(mesh, visitor)
+		return nil
+	} else if mesh.MajorVersion < 6 || mesh.MajorVersion > 16 {
+		panic(parserError{fmt.Sprintf("Major version %v is outside of 6-16 and %v-%v", mesh.MajorVersion, minPolygonMajorVersion, maxPolygonMajorVersion), ErrUnsupportedVersion, 4})
 	}
 
 	if mesh.MajorVersion >= 10 {
-		p.read(&mesh.MinorVersion)
+		mesh.MinorVersion = p.readUint32()
 	}
 
-	p.read(&mesh.BSPSize)
+	mesh.BSPSize = p.readUint32()
 
 	if mesh.MajorVersion >= 14 {
-		p.read(&mesh.IsMeshAnalyzed)
+		mesh.IsMeshAnalyzed = p.readBool()
 	}
 
+	p.visit(visitor.VisitHeader(mesh))
+
 	// Let's get the "places"
+	p.section = ParseSectionPlaces
 	mesh.Places = make(map[uint32]*NavPlace)
-	var placeCount uint16
-	p.read(&placeCount)
+	placeCount := p.readUint16()
+	p.checkCount("places", uint32(placeCount), p.Options.MaxPlaces)
 
 	for i := uint16(0); i < placeCount; i++ {
 		id := uint32(i + 1)
-		var nameLength uint16
-		p.read(&nameLength)
-		mesh.Places[id] = &NavPlace{ID: id, Name: p.readString(nameLength)[:nameLength-1]}
+		offset := p.input.offset
+		nameLength := p.readUint16()
+
+		if nameLength == 0 {
+			panic(parserError{fmt.Sprintf("Place %v has no name, not even a null terminator", id), nil, offset})
+		}
+
+		name := p.readString(nameLength)
+
+		// Names are null-terminated, but remember the ones that weren't so they're written back the same way
+		unterminated := name[len(name)-1] != 0
+		if !unterminated {
+			name = name[:len(name)-1]
+		}
+
+		mesh.Places[id] = &NavPlace{ID: id, Name: name, unterminated: unterminated}
+		p.visit(visitor.VisitPlace(mesh.Places[id]))
 	}
 
 	// Time to build the area objects
-	mesh.Areas = make(map[uint32]*NavArea)
-	mesh.QuadTreeAreas = &quadTreeNode{NorthWestPoint: Vector3{-16384, -16384, 0}, SouthEastPoint: Vector3{16384, 16384, 0}}
+	p.section = ParseSectionAreas
 
-	var hasUnnamedAreas bool
 	if mesh.MajorVersion > 11 {
-		p.read(&hasUnnamedAreas)
+		mesh.HasUnnamedAreas = p.readBool()
 	}
 
-	var areaCount uint32
-	p.read(&areaCount)
+	// Game-specific mesh data
+	mesh.Profile = profileOrDefault(p.Profile)
+	p.readCustom(func() error { return mesh.Profile.DecodeMeshData(p.input, mesh) })
+
+	areaCount := p.readUint32()
+	p.checkCount("areas", areaCount, p.Options.MaxAreas)
 
 	for i := uint32(0); i < areaCount; i++ {
+		p.checkContext()
+
 		var currArea NavArea
-		p.read(&currArea.ID)
+		p.areaIndex = int(i)
+		p.areaID = 0
+		currArea.ID = p.readUint32()
+		p.areaID = currArea.ID
 
 		if mesh.MajorVersion <= 8 {
-			var flags byte
-			p.read(&flags)
-			currArea.Flags = uint32(flags)
+			currArea.Flags = uint32(p.readByte())
 		} else if mesh.MajorVersion < 13 {
-			var flags uint16
-			p.read(&flags)
-			currArea.Flags = uint32(flags)
+			currArea.Flags = uint32(p.readUint16())
 		} else {
-			p.read(&currArea.Flags)
+			currArea.Flags = p.readUint32()
 		}
 
-		p.read(&currArea.NorthWest)
-		p.read(&currArea.SouthEast)
-		p.read(&currArea.NorthEastZ)
-		p.read(&currArea.SouthWestZ)
+		currArea.NorthWest = p.readVector3()
+		currArea.SouthEast = p.readVector3()
+		currArea.NorthEastZ = p.readFloat32()
+		currArea.SouthWestZ = p.readFloat32()
 
 		// Time to handle the connections
 		for direction := NavDirection(0); direction < NavDirectionMax; direction++ {
-			var connectionCount uint32
-			p.read(&connectionCount)
+			connectionCount := p.readUint32()
+			p.checkCount("connections", connectionCount, p.Options.MaxConnections)
 
 			for connectionIndex := uint32(0); connectionIndex < connectionCount; connectionIndex++ {
 				var currConnection NavConnection
 				currConnection.SourceArea = &currArea
 				currConnection.Direction = direction
-				p.read(&currConnection.TargetAreaID)
+				currConnection.TargetAreaID = p.readUint32()
 
 				currArea.Connections = append(currArea.Connections, &currConnection)
 			}
 		}
 
 		// Time to handle the spots
-		var hidingSpotCount byte
-		p.read(&hidingSpotCount)
+		hidingSpotCount := p.readByte()
+		p.checkCount("hiding spots", uint32(hidingSpotCount), p.Options.MaxHidingSpots)
 
 		for hidingIndex := byte(0); hidingIndex < hidingSpotCount; hidingIndex++ {
 			var currSpot NavHidingSpot
-			p.read(&currSpot.ID)
-			p.read(&currSpot.Location)
-			p.read(&currSpot.Flags)
+			currSpot.ID = p.readUint32()
+			currSpot.Location = p.readVector3()
+			currSpot.Flags = NavHidingSpotFlags(p.readByte())
 
 			currArea.HidingSpots = append(currArea.HidingSpots, &currSpot)
 		}
 
-		// Throw away garbage if this is old
+		// Handle approach areas if this is old
 		if mesh.MajorVersion < 15 {
-			var approachAreaCount byte
-			p.read(&approachAreaCount)
-
-			// Advance the pointer to skip past this garbage.
-			// What is actually happening here is the following struct laid end to end
-			// apprachAreaCount times.
-			// struct
-			// {
-			//      uint approachHereId;
-			//      uint approachPrevId;
-			//      byte approachType;
-			//      uint approachNextId;
-			//      byte approachHow;
-			// }
-			p.advanceBytes((4*3 + 2) * int(approachAreaCount))
+			approachAreaCount := p.readByte()
+
+			for approachIndex := byte(0); approachIndex < approachAreaCount; approachIndex++ {
+				var currApproach NavApproachArea
+				currApproach.HereAreaID = p.readUint32()
+				currApproach.PrevAreaID = p.readUint32()
+				currApproach.PrevToHereHow = p.readByte()
+				currApproach.NextAreaID = p.readUint32()
+				currApproach.HereToNextHow = p.readByte()
+
+				currArea.ApproachAreas = append(currArea.ApproachAreas, &currApproach)
+			}
 		}
 
 		// Handle encounter paths
-		var encounterPathCount uint32
-		p.read(&encounterPathCount)
+		encounterPathCount := p.readUint32()
+		p.checkCount("encounter paths", encounterPathCount, p.Options.MaxEncounterPaths)
 
 		for pathIndex := uint32(0); pathIndex < encounterPathCount; pathIndex++ {
 			var currPath NavEncounterPath
-			p.read(&currPath.FromAreaID)
-			p.read(&currPath.FromDirection)
-			p.read(&currPath.ToAreaID)
-			p.read(&currPath.ToDirection)
+			currPath.FromAreaID = p.readUint32()
+			currPath.FromDirection = p.readDirection()
+			currPath.ToAreaID = p.readUint32()
+			currPath.ToDirection = p.readDirection()
 
-			var spotCount byte
-			p.read(&spotCount)
+			spotCount := p.readByte()
 
 			for spotIndex := byte(0); spotIndex < spotCount; spotIndex++ {
 				var currSpot NavEncounterSpot
-				p.read(&currSpot.OrderID)
-				var distance byte
-				p.read(&distance)
-				currSpot.ParametricDistiance = float32(distance) / 255
+				currSpot.OrderID = p.readUint32()
+				currSpot.ParametricDistiance = float32(p.readByte()) / 255
 
 				currPath.Spots = append(currPath.Spots, &currSpot)
 			}
@@ -202,163 +251,191 @@ func (p *Parser) Parse() (mesh NavMesh, err error) {
 		}
 
 		// Handle places
-		var placeID uint16
-		p.read(&placeID)
-		place, ok := mesh.Places[uint32(placeID)]
-
-		if ok {
-			currArea.Place = place
-			place.Areas = append(place.Areas, &currArea)
-		}
+		placeID := p.readUint16()
+		currArea.Place = mesh.Places[uint32(placeID)]
 
 		// Handle ladders
 		for currDirection := NavLadderDirection(0); currDirection < NavLadderDirectionMax; currDirection++ {
-			var ladderConnectionCount uint32
-			p.read(&ladderConnectionCount)
+			ladderConnectionCount := p.readUint32()
+			p.checkCount("ladder connections", ladderConnectionCount, p.Options.MaxLadderConnections)
 
 			for connectionIndex := uint32(0); connectionIndex < ladderConnectionCount; connectionIndex++ {
 				var currConnection NavLadderConnection
 				currConnection.SourceArea = &currArea
 				currConnection.Direction = currDirection
-				p.read(&currConnection.TargetID)
+				currConnection.TargetID = p.readUint32()
 
 				currArea.LadderConnections = append(currArea.LadderConnections, &currConnection)
 			}
 		}
 
 		// Occupy times
-		p.read(&currArea.EarliestOccupyTimeFirstTeam)
-		p.read(&currArea.EarliestOccupyTimeSecondTeam)
+		currArea.EarliestOccupyTimeFirstTeam = p.readFloat32()
+		currArea.EarliestOccupyTimeSecondTeam = p.readFloat32()
 
 		// Light intensity
 		if mesh.MajorVersion >= 11 {
-			p.read(&currArea.NorthWestLightIntensity)
-			p.read(&currArea.NorthEastLightIntensity)
-			p.read(&currArea.SouthEastLightIntensity)
-			p.read(&currArea.SouthWestLightIntensity)
+			currArea.NorthWestLightIntensity = p.readFloat32()
+			currArea.NorthEastLightIntensity = p.readFloat32()
+			currArea.SouthEastLightIntensity = p.readFloat32()
+			currArea.SouthWestLightIntensity = p.readFloat32()
 		}
 
 		// Visible areas
 		if mesh.MajorVersion >= 16 {
-			var visibleAreaCount uint32
-			p.read(&visibleAreaCount)
+			visibleAreaCount := p.readUint32()
+			p.checkCount("visible areas", visibleAreaCount, p.Options.MaxVisibleAreas)
 
 			for visibleIndex := uint32(0); visibleIndex < visibleAreaCount; visibleIndex++ {
 				var currVisible NavVisibleArea
-				p.read(&currVisible.VisibleAreaID)
-				p.read(&currVisible.Attributes)
+				currVisible.VisibleAreaID = p.readUint32()
+				currVisible.Attributes = NavVisibilityFlags(p.readByte())
 
 				currArea.VisibleAreas = append(currArea.VisibleAreas, &currVisible)
 			}
 		}
 
-		p.read(&currArea.InheritVisibilityFromAreaID)
+		currArea.InheritVisibilityFromAreaID = p.readUint32()
 
-		// Skip passed some unknown data
-		var garbageCount byte
-		p.read(&garbageCount)
-		p.advanceBytes(int(garbageCount) * 14)
+		// Game-specific area data
+		p.readCustom(func() error { return mesh.Profile.DecodeAreaData(p.input, mesh, &currArea) })
 
-		mesh.Areas[currArea.ID] = &currArea
-		mesh.QuadTreeAreas.InsertArea(&currArea)
+		p.visit(visitor.VisitArea(&currArea))
 	}
 
 	// Time to build the ladder objects
-	mesh.Ladders = make(map[uint32]*NavLadder)
-	var ladderCount uint32
-	p.read(&ladderCount)
+	p.section = ParseSectionLadders
+	p.areaIndex = -1
+	p.areaID = 0
+	ladderCount := p.readUint32()
+	p.checkCount("ladders", ladderCount, p.Options.MaxLadders)
 
 	for ladderIndex := uint32(0); ladderIndex < ladderCount; ladderIndex++ {
+		p.checkContext()
+
 		var currLadder NavLadder
 
-		p.read(&currLadder.ID)
-		p.read(&currLadder.Width)
-		p.read(&currLadder.Top)
-		p.read(&currLadder.Bottom)
-		p.read(&currLadder.Length)
-		p.read(&currLadder.Direction)
+		currLadder.ID = p.readUint32()
+		currLadder.Width = p.readFloat32()
+		currLadder.Top = p.readVector3()
+		currLadder.Bottom = p.readVector3()
+		currLadder.Length = p.readFloat32()
+		currLadder.Direction = p.readLadderDirection()
 
-		p.read(&currLadder.TopForwardAreaID)
-		currLadder.TopForwardArea = mesh.Areas[currLadder.TopForwardAreaID]
+		currLadder.TopForwardAreaID = p.readUint32()
+		currLadder.TopLeftAreaID = p.readUint32()
+		currLadder.TopRightAreaID = p.readUint32()
+		currLadder.TopBehindAreaID = p.readUint32()
+		currLadder.BottomAreaID = p.readUint32()
 
-		p.read(&currLadder.TopLeftAreaID)
-		currLadder.TopLeftArea = mesh.Areas[currLadder.TopLeftAreaID]
+		p.visit(visitor.VisitLadder(&currLadder))
+	}
 
-		p.read(&currLadder.TopRightAreaID)
-		currLadder.TopRightArea = mesh.Areas[currLadder.TopRightAreaID]
+	return nil
+}
 
-		p.read(&currLadder.TopBehindAreaID)
-		currLadder.TopBehindArea = mesh.Areas[currLadder.TopBehindAreaID]
+func (p *Parser) readByte() byte {
+	offset := p.input.offset
+	value, err := p.input.uint8()
+	p.check(err, offset)
+	return value
+}
 
-		p.read(&currLadder.BottomAreaID)
-		currLadder.BottomArea = mesh.Areas[currLadder.BottomAreaID]
+func (p *Parser) readBool() bool {
+	return p.readByte() > 0
+}
 
-		mesh.Ladders[currLadder.ID] = &currLadder
-	}
+func (p *Parser) readUint16() uint16 {
+	offset := p.input.offset
+	value, err := p.input.uint16()
+	p.check(err, offset)
+	return value
+}
 
-	// Ok we're done parsing the file, now it's time to connect the graph
-	mesh.connectGraph()
+func (p *Parser) readUint32() uint32 {
+	offset := p.input.offset
+	value, err := p.input.uint32()
+	p.check(err, offset)
+	return value
+}
 
-	return mesh, nil
+func (p *Parser) readUint64() uint64 {
+	offset := p.input.offset
+	value, err := p.input.uint64()
+	p.check(err, offset)
+	return value
 }
 
-func (p *Parser) read(data interface{}) {
-	switch t := data.(type) {
-	case *Vector3:
-		var x, y, z float32
-		p.read(&x)
-		p.read(&y)
-		p.read(&z)
-		*t = Vector3{x, y, z}
-
-	case *bool:
-		var b byte
-		p.read(&b)
-		*t = b > 0
-
-	case *NavDirection:
-		var b byte
-		p.read(&b)
-		*t = NavDirection(b)
-
-	case *NavLadderDirection:
-		var i uint32
-		p.read(&i)
-		*t = NavLadderDirection(i)
-
-	default:
-		err := binary.Read(p.Reader, binary.LittleEndian, t)
-
-		if err != nil {
-			panic(parserError{"Failed to read data", err})
-		}
-	}
+func (p *Parser) readFloat32() float32 {
+	offset := p.input.offset
+	value, err := p.input.float32()
+	p.check(err, offset)
+	return value
+}
+
+func (p *Parser) readVector3() Vector3 {
+	offset := p.input.offset
+	value, err := p.input.vector3()
+	p.check(err, offset)
+	return value
+}
+
+func (p *Parser) readDirection() NavDirection {
+	return NavDirection(p.readByte())
+}
+
+func (p *Parser) readLadderDirection() NavLadderDirection {
+	return NavLadderDirection(p.readUint32())
 }
 
 func (p *Parser) readString(length uint16) string {
-	data := make([]byte, length)
-	n, ok := p.Reader.Read(data)
+	offset := p.input.offset
+	value, err := p.input.string(int(length))
 
-	if ok != nil {
-		panic(parserError{"Failed to read string data", ok})
-	} else if n != int(length) {
-		panic(parserError{"Failed to read string data, length incorrect", nil})
+	if err != nil {
+		panic(parserError{"Failed to read string data", err, offset})
 	}
 
-	return string(data)
+	return value
+}
+
+// check fails the parse if reading the value at the specified offset failed
+func (p *Parser) check(err error, offset int64) {
+	if err != nil {
+		panic(parserError{"Failed to read data", err, offset})
+	}
 }
 
-func (p *Parser) advanceBytes(length int) {
-	data := make([]byte, length)
-	n, ok := p.Reader.Read(data)
+func (p *Parser) readCustom(decode func() error) {
+	offset := p.input.offset
+
+	if err := decode(); err != nil {
+		panic(parserError{"Failed to read custom data", unexpectedEOF(err), offset})
+	}
+}
 
-	if ok != nil {
-		panic(parserError{"Failed to advance data", ok})
-	} else if n != int(length) {
-		panic(parserError{"Failed to advance data, length incorrect", nil})
+func (p *Parser) visit(err error) {
+	if err != nil {
+		panic(parserError{"Visitor failed", err, p.input.offset})
 	}
 }
 
-func buildParseError(err string) (NavMesh, error) {
-	return NavMesh{}, errors.New(err)
+func (p *Parser) buildParseError(msg parserError) *ParseError {
+	return &ParseError{
+		Offset:    msg.Offset,
+		Section:   p.section,
+		AreaIndex: p.areaIndex,
+		AreaID:    p.areaID,
+		Message:   msg.Message,
+		Err:       msg.Error}
+}
+
+// unexpectedEOF converts io.EOF into io.ErrUnexpectedEOF since the end of
+// the input is never expected in the middle of a .nav file
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
 }
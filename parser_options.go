@@ -0,0 +1,80 @@
+/*
+	gonav - A Source Engine navigation mesh file parser written in Go.
+	Copyright (C) 2016  Matt Razza
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as published
+	by the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package gonav provides functionality related to CS:GO Nav Meshes
+package gonav
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrLimitExceeded is returned when the input exceeds one of the limits in ParserOptions
+var ErrLimitExceeded = errors.New("Nav mesh exceeds the configured parser limits.")
+
+// ParserOptions limits the resources a Parser may use. This should be used when parsing
+// untrusted input. Limits that are zero are not enforced.
+type ParserOptions struct {
+	Context              context.Context // Cancels parsing once done; nil to never cancel
+	MaxBytes             int64           // Maximum number of bytes to read from the input
+	MaxPlaces            uint32          // Maximum number of places in the mesh
+	MaxAreas             uint32          // Maximum number of areas in the mesh
+	MaxConnections       uint32          // Maximum number of connections per area in a single direction
+	MaxHidingSpots       uint32          // Maximum number of hiding spots per area
+	MaxEncounterPaths    uint32          // Maximum number of encounter paths per area
+	MaxLadderConnections uint32          // Maximum number of ladder connections per area in a single direction
+	MaxVisibleAreas      uint32          // Maximum number of visible areas per area
+	MaxLadders           uint32          // Maximum number of ladders in the mesh
+	MaxCorners           uint32          // Maximum number of polygon corners in the mesh (CS2)
+	MaxPolygons          uint32          // Maximum number of polygons in the mesh (CS2)
+}
+
+// DefaultParserOptions gets a set of limits that comfortably fit any shipped map
+func DefaultParserOptions() ParserOptions {
+	return ParserOptions{
+		MaxBytes:             256 << 20,
+		MaxPlaces:            1 << 12,
+		MaxAreas:             1 << 20,
+		MaxConnections:       1 << 12,
+		MaxHidingSpots:       1 << 8,
+		MaxEncounterPaths:    1 << 16,
+		MaxLadderConnections: 1 << 12,
+		MaxVisibleAreas:      1 << 20,
+		MaxLadders:           1 << 16,
+		MaxCorners:           1 << 22,
+		MaxPolygons:          1 << 20}
+}
+
+// checkCount makes sure the specified count is within the specified limit
+func (p *Parser) checkCount(name string, count, limit uint32) {
+	if limit > 0 && count > limit {
+		panic(parserError{fmt.Sprintf("%v %s exceeds the limit of %v", count, name, limit), ErrLimitExceeded, p.input.offset})
+	}
+}
+
+// checkContext makes sure parsing has not been cancelled
+func (p *Parser) checkContext() {
+	if p.Options.Context == nil {
+		return
+	}
+
+	if err := p.Options.Context.Err(); err != nil {
+		panic(parserError{"Parsing was cancelled", err, p.input.offset})
+	}
+}
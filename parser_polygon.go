@@ -0,0 +1,204 @@
+/*
+	gonav - A Source Engine navigation mesh file parser written in Go.
+	Copyright (C) 2016  Matt Razza
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as published
+	by the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package gonav provides functionality related to CS:GO Nav Meshes
+package gonav
+
+import "fmt"
+
+const (
+	minPolygonMajorVersion uint32 = 30 // The first major version using polygonal areas (CS2)
+	maxPolygonMajorVersion uint32 = 36 // The last known major version using polygonal areas (CS2)
+)
+
+// CS2AreaData is the CS2 specific data of a polygonal NavArea
+type CS2AreaData struct {
+	AttributeFlags uint64 // The full 64-bit attribute flags; the low 32 bits are also in NavArea.Flags
+	HullIndex      byte   // The index of the hull size the area was generated for
+}
+
+// CS2Data gets the CS2 specific data of this area; nil if the area is not from a CS2 mesh
+func (area *NavArea) CS2Data() *CS2AreaData {
+	data, _ := area.CustomData.(*CS2AreaData)
+	return data
+}
+
+// parsePolygonMesh parses the body of a CS2 nav mesh, which is laid out as follows
+// (values that are not understood are skipped):
+//
+//	uint32 flags                          bit 0 is IsMeshAnalyzed
+//	if version >= 31:
+//	    uint32 cornerCount, Vector3 corners[cornerCount]
+//	    uint32 polygonCount, polygons[polygonCount]:
+//	        byte count, uint32 cornerIndex[count]
+//	        if version >= 35: uint32 unknown
+//	if version >= 32: uint32 unknown
+//	if version >= 35: uint32 unknown
+//	uint32 areaCount, areas[areaCount]:
+//	    uint32 id, uint64 attributeFlags, byte hullIndex
+//	    if version >= 31: uint32 polygonIndex
+//	    else:             uint32 count, Vector3 corners[count]
+//	    float32 unknown
+//	    for each edge: uint32 count, {uint32 targetAreaID, uint32 targetEdge}[count]
+//	    byte legacyHidingSpotCount, uint32 legacySpotCount (both always zero)
+//	    uint32 count, uint32 ladderAboveIDs[count]
+//	    uint32 count, uint32 ladderBelowIDs[count]
+//
+// Anything after the areas, including the ladders, is ignored. Since the ladders are never read, the ladder IDs of
+// the areas are skipped rather than turned into LadderConnections that could not be resolved. If either is skipped,
+// the mesh is marked IsPartial once the areas are read.
+func (p *Parser) parsePolygonMesh(mesh *NavMesh, visitor NavVisitor) {
+	mesh.MinorVersion = p.readUint32()
+	mesh.IsMeshAnalyzed = p.readUint32()&1 > 0
+	mesh.Places = make(map[uint32]*NavPlace)
+	mesh.Profile = profileOrDefault(p.Profile)
+
+	var polygons [][]Vector3
+	if mesh.MajorVersion >= 31 {
+		cornerCount := p.readUint32()
+		p.checkCount("corners", cornerCount, p.Options.MaxCorners)
+
+		// The count isn't trusted, so the corners are appended rather than allocated up front
+		var corners []Vector3
+		for i := uint32(0); i < cornerCount; i++ {
+			corners = append(corners, p.readVector3())
+		}
+
+		polygonCount := p.readUint32()
+		p.checkCount("polygons", polygonCount, p.Options.MaxPolygons)
+
+		for i := uint32(0); i < polygonCount; i++ {
+			polygonCornerCount := p.readByte()
+			polygon := make([]Vector3, 0, polygonCornerCount)
+
+			for j := byte(0); j < polygonCornerCount; j++ {
+				offset := p.input.offset
+				cornerIndex := p.readUint32()
+
+				if cornerIndex >= uint32(len(corners)) {
+					panic(parserError{fmt.Sprintf("Corner index %v is outside of the %v corners", cornerIndex, len(corners)), nil, offset})
+				}
+
+				polygon = append(polygon, corners[cornerIndex])
+			}
+
+			if mesh.MajorVersion >= 35 {
+				p.readUint32()
+			}
+
+			polygons = append(polygons, polygon)
+		}
+	}
+
+	if mesh.MajorVersion >= 32 {
+		p.readUint32()
+	}
+
+	if mesh.MajorVersion >= 35 {
+		p.readUint32()
+	}
+
+	p.visit(visitor.VisitHeader(mesh))
+
+	p.section = ParseSectionAreas
+	areaCount := p.readUint32()
+	p.checkCount("areas", areaCount, p.Options.MaxAreas)
+
+	for i := uint32(0); i < areaCount; i++ {
+		p.checkContext()
+
+		var currArea NavArea
+		var currData CS2AreaData
+		p.areaIndex = int(i)
+		p.areaID = 0
+		currArea.ID = p.readUint32()
+		p.areaID = currArea.ID
+
+		currData.AttributeFlags = p.readUint64()
+		currData.HullIndex = p.readByte()
+		currArea.Flags = uint32(currData.AttributeFlags)
+		currArea.CustomData = &currData
+
+		if mesh.MajorVersion >= 31 {
+			offset := p.input.offset
+			polygonIndex := p.readUint32()
+
+			if polygonIndex >= uint32(len(polygons)) {
+				panic(parserError{fmt.Sprintf("Polygon index %v is outside of the %v polygons", polygonIndex, len(polygons)), nil, offset})
+			}
+
+			currArea.Corners = append([]Vector3(nil), polygons[polygonIndex]...)
+		} else {
+			cornerCount := p.readUint32()
+			p.checkCount("corners", cornerCount, p.Options.MaxCorners)
+
+			for j := uint32(0); j < cornerCount; j++ {
+				currArea.Corners = append(currArea.Corners, p.readVector3())
+			}
+		}
+
+		if len(currArea.Corners) < 3 {
+			panic(parserError{fmt.Sprintf("Polygon has %v corners", len(currArea.Corners)), nil, p.input.offset})
+		}
+
+		currArea.setPolygonBounds()
+		p.readFloat32()
+
+		// Time to handle the connections, one list per edge
+		for edge := range currArea.Corners {
+			connectionCount := p.readUint32()
+			p.checkCount("connections", connectionCount, p.Options.MaxConnections)
+
+			for connectionIndex := uint32(0); connectionIndex < connectionCount; connectionIndex++ {
+				var currConnection NavConnection
+				currConnection.SourceArea = &currArea
+				currConnection.Edge = edge
+				currConnection.Direction = currArea.edgeDirection(edge)
+				currConnection.TargetAreaID = p.readUint32()
+				currConnection.TargetEdge = p.readUint32()
+
+				currArea.Connections = append(currArea.Connections, &currConnection)
+			}
+		}
+
+		// Legacy hiding spots and encounter spots; always empty
+		p.readByte()
+		p.readUint32()
+
+		// Skip the ladders; see above
+		for currDirection := NavLadderDirection(0); currDirection < NavLadderDirectionMax; currDirection++ {
+			ladderConnectionCount := p.readUint32()
+			p.checkCount("ladder connections", ladderConnectionCount, p.Options.MaxLadderConnections)
+
+			for connectionIndex := uint32(0); connectionIndex < ladderConnectionCount; connectionIndex++ {
+				p.readUint32()
+			}
+
+			if ladderConnectionCount > 0 {
+				mesh.IsPartial = true
+			}
+		}
+
+		p.visit(visitor.VisitArea(&currArea))
+	}
+
+	// The ladders and whatever else follows the areas
+	if !p.input.atEOF() {
+		mesh.IsPartial = true
+	}
+}
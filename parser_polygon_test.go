@@ -0,0 +1,113 @@
+/*
+	gonav - A Source Engine navigation mesh file parser written in Go.
+	Copyright (C) 2016  Matt Razza
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as published
+	by the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package gonav provides functionality related to CS:GO Nav Meshes
+package gonav
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// polygonTestMesh builds a version 31 mesh with a single triangle that lists ladders above and below it
+func polygonTestMesh() []byte {
+	var buffer bytes.Buffer
+	write := func(data interface{}) { binary.Write(&buffer, binary.LittleEndian, data) }
+
+	write(uint32(0xFEEDFACE))
+	write(uint32(31)) // Major version
+	write(uint32(0))  // Minor version
+	write(uint32(1))  // Flags
+	write(uint32(3))  // Corners
+	write([3][3]float32{{0, 0, 0}, {100, 0, 0}, {0, 100, 0}})
+	write(uint32(1)) // Polygons
+	write(byte(3))
+	write([3]uint32{0, 1, 2})
+	write(uint32(1)) // Areas
+	write(uint32(1))
+	write(uint64(0))
+	write(byte(0))
+	write(uint32(0)) // Polygon index
+	write(float32(0))
+	write([3]uint32{0, 0, 0}) // No connections on any edge
+	write(byte(0))
+	write(uint32(0))
+	write([2]uint32{1, 5}) // One ladder above
+	write([2]uint32{1, 6}) // One ladder below
+
+	return buffer.Bytes()
+}
+
+func TestParsePolygonLadders(t *testing.T) {
+	parser := Parser{Reader: bytes.NewReader(polygonTestMesh())}
+	mesh, err := parser.Parse()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count := len(mesh.Areas[1].LadderConnections); count != 0 {
+		t.Fatalf("Area has %v ladder connections to ladders that were never read.", count)
+	}
+
+	if report := mesh.Validate(); report.HasErrors() {
+		t.Fatalf("Validation failed: %v", report.Findings)
+	}
+}
+
+func TestParsePolygonMarksSkippedSections(t *testing.T) {
+	withLadders := polygonTestMesh()
+	withoutLadders := append(withLadders[:len(withLadders)-16:len(withLadders)-16], 0, 0, 0, 0, 0, 0, 0, 0)
+	withTrailingData := append(withoutLadders[:len(withoutLadders):len(withoutLadders)], 0, 0, 0, 0)
+
+	for _, test := range []struct {
+		name    string
+		data    []byte
+		partial bool
+	}{
+		{"ladder IDs", withLadders, true},
+		{"nothing skipped", withoutLadders, false},
+		{"data after the areas", withTrailingData, true},
+	} {
+		parser := Parser{Reader: bytes.NewReader(test.data)}
+		mesh, err := parser.Parse()
+		if err != nil {
+			t.Fatalf("%v: %v", test.name, err)
+		}
+
+		if mesh.IsPartial != test.partial {
+			t.Errorf("%v: IsPartial is %v, expected %v.", test.name, mesh.IsPartial, test.partial)
+		}
+
+		if findings := mesh.Validate().Filter(ValidationKindPartialMesh); (len(findings) > 0) != test.partial {
+			t.Errorf("%v: Validate reported %v partial mesh findings.", test.name, len(findings))
+		}
+	}
+}
+
+func TestParsePolygonCornerCount(t *testing.T) {
+	// A header claiming billions of corners must fail on the missing data rather than allocate them up front
+	data := polygonTestMesh()[:16]
+	data = binary.LittleEndian.AppendUint32(data, 0x7FFFFFFF)
+
+	parser := Parser{Reader: bytes.NewReader(data)}
+	if _, err := parser.Parse(); err == nil {
+		t.Fatal("Parsed a mesh with missing corners.")
+	}
+}
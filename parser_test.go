@@ -0,0 +1,137 @@
+/*
+	gonav - A Source Engine navigation mesh file parser written in Go.
+	Copyright (C) 2016  Matt Razza
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as published
+	by the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package gonav provides functionality related to CS:GO Nav Meshes
+package gonav
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestParseWithoutReader(t *testing.T) {
+	var parser Parser
+	_, err := parser.Parse()
+
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || !errors.Is(err, ErrNoReader) {
+		t.Errorf("Expected a *ParseError wrapping ErrNoReader, got %v.", err)
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	mesh := newTestMesh(3)
+
+	for _, currVersion := range []uint32{6, 9, 11, 13, 14, 15, 16} {
+		f.Add(writeTestMesh(f, mesh, currVersion))
+	}
+
+	f.Add(polygonTestMesh())
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// Malformed input must fail with an error, never a panic or a runaway allocation
+		parser := Parser{Reader: bytes.NewReader(data)}
+		mesh, err := parser.Parse()
+
+		if err == nil {
+			mesh.Validate()
+			mesh.WriteTo(io.Discard)
+		}
+	})
+}
+
+func TestParseEmptyPlaceName(t *testing.T) {
+	// Version 6 header with a single place whose name length is 0
+	data := []byte{0xCE, 0xFA, 0xED, 0xFE, 6, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0}
+	parser := Parser{Reader: bytes.NewReader(data)}
+	_, err := parser.Parse()
+
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || parseErr.Section != ParseSectionPlaces || parseErr.Offset != 14 {
+		t.Errorf("Expected a *ParseError at the place name length, got %v.", err)
+	}
+}
+
+// panickingProfile is a GameProfile with a bug in its mesh decoder
+type panickingProfile struct {
+	CSGOProfile
+}
+
+var errProfileBug = errors.New("Profile bug.")
+
+func (panickingProfile) DecodeMeshData(r io.Reader, mesh *NavMesh) error {
+	panic(errProfileBug)
+}
+
+func TestParseLetsProfilePanicsThrough(t *testing.T) {
+	defer func() {
+		if r := recover(); r != errProfileBug {
+			t.Errorf("Expected the profile's panic to reach the caller, got %v.", r)
+		}
+	}()
+
+	parser := Parser{Reader: bytes.NewReader(writeTestMesh(t, newTestMesh(2), 16)), Profile: panickingProfile{}}
+	parser.Parse()
+}
+
+// BenchmarkParse parses a 22,500 area mesh
+func BenchmarkParse(b *testing.B) {
+	data := writeTestMesh(b, newTestMesh(150), 16)
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		parser := Parser{Reader: bytes.NewReader(data)}
+
+		if _, err := parser.Parse(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// countingVisitor counts the areas it visits and keeps nothing
+type countingVisitor struct {
+	areas int
+}
+
+func (visitor *countingVisitor) VisitHeader(mesh *NavMesh) error     { return nil }
+func (visitor *countingVisitor) VisitPlace(place *NavPlace) error    { return nil }
+func (visitor *countingVisitor) VisitArea(area *NavArea) error       { visitor.areas++; return nil }
+func (visitor *countingVisitor) VisitLadder(ladder *NavLadder) error { return nil }
+
+// BenchmarkParseStream streams the same 22,500 area mesh as BenchmarkParse without building it
+func BenchmarkParseStream(b *testing.B) {
+	data := writeTestMesh(b, newTestMesh(150), 16)
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		var visitor countingVisitor
+		parser := Parser{Reader: bytes.NewReader(data)}
+
+		if err := parser.ParseStream(&visitor); err != nil {
+			b.Fatal(err)
+		}
+
+		if visitor.areas != 150*150 {
+			b.Fatalf("Visited %v areas.", visitor.areas)
+		}
+	}
+}
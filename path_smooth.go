@@ -0,0 +1,144 @@
+/*
+	gonav - A Source Engine navigation mesh file parser written in Go.
+	Copyright (C) 2016  Matt Razza
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as published
+	by the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package gonav provides functionality related to CS:GO Nav Meshes
+package gonav
+
+// Smooth turns this path into world-space waypoints from start to end by running the simple stupid funnel
+// algorithm over the portals between consecutive areas. Ladders and portals with a height change of more than a
+// step break the funnel: their ends are always waypoints, and the funnel restarts on the far side.
+// The first waypoint is start and the last is end; nil if the path is empty.
+func (p *Path) Smooth(start, end Vector3) []Vector3 {
+	if len(p.Nodes) == 0 {
+		return nil
+	}
+
+	waypoints := []Vector3{start}
+	portals := []navPortal{{start, start}}
+
+	// flush funnels the portals so far through to the specified point, then restarts the funnel at restart
+	flush := func(to Vector3, restart Vector3) {
+		portals = append(portals, navPortal{to, to})
+		waypoints = funnel(portals, waypoints)
+		waypoints = appendWaypoint(waypoints, restart)
+		portals = append(portals[:0], navPortal{restart, restart})
+	}
+
+	for i := 1; i < len(p.Nodes); i++ {
+		prevNode, currNode := p.Nodes[i-1], p.Nodes[i]
+
+		if currNode.Ladder != nil {
+			entry, exit := currNode.Ladder.ends(currNode.LadderDirection)
+			flush(entry, exit)
+			continue
+		}
+
+		connection := currNode.Connection
+		if connection == nil {
+			if connections := prevNode.Area.GetConnectionsTo(currNode.Area); len(connections) > 0 {
+				connection = connections[0]
+			}
+		}
+
+		if connection == nil {
+			// The areas aren't connected, so there's no portal; go through the middle of the area instead
+			center := currNode.Area.GetCenter()
+			flush(center, center)
+			continue
+		}
+
+		left, right := connection.Portal()
+
+		// Whether the areas link back doesn't matter; dropping down or jumping up always breaks the funnel
+		if connection.levelChange {
+			flush(connection.portalMiddles())
+			continue
+		}
+
+		portals = append(portals, navPortal{left, right})
+	}
+
+	portals = append(portals, navPortal{end, end})
+	return funnel(portals, waypoints)
+}
+
+// navPortal is a portal crossed by a path, with its ends as seen when crossing it
+type navPortal struct {
+	left  Vector3 // The left end of the portal
+	right Vector3 // The right end of the portal
+}
+
+// funnel appends the corners of the shortest path through the specified portals to waypoints. The first portal
+// is the apex the path starts at, which must already be in waypoints, and the last is the point it ends at.
+func funnel(portals []navPortal, waypoints []Vector3) []Vector3 {
+	apex, left, right := portals[0].left, portals[0].left, portals[0].left
+	apexIndex, leftIndex, rightIndex := 0, 0, 0
+
+	for i := 1; i < len(portals); i++ {
+		currLeft, currRight := portals[i].left, portals[i].right
+
+		// Does the right side of the funnel narrow?
+		if triangleArea2(apex, right, currRight) >= 0 {
+			if samePoint(apex, right) || triangleArea2(apex, left, currRight) < 0 {
+				right, rightIndex = currRight, i
+			} else {
+				// The right side crossed the left side, so the left side is a corner
+				waypoints = appendWaypoint(waypoints, left)
+				apex, apexIndex = left, leftIndex
+				right, rightIndex = apex, apexIndex
+				i = apexIndex
+				continue
+			}
+		}
+
+		// Does the left side of the funnel narrow?
+		if triangleArea2(apex, left, currLeft) <= 0 {
+			if samePoint(apex, left) || triangleArea2(apex, right, currLeft) > 0 {
+				left, leftIndex = currLeft, i
+			} else {
+				// The left side crossed the right side, so the right side is a corner
+				waypoints = appendWaypoint(waypoints, right)
+				apex, apexIndex = right, rightIndex
+				left, leftIndex = apex, apexIndex
+				i = apexIndex
+				continue
+			}
+		}
+	}
+
+	return appendWaypoint(waypoints, portals[len(portals)-1].left)
+}
+
+// triangleArea2 gets twice the signed XY area of the triangle a, b, c; positive if c is left of the line from a to b
+func triangleArea2(a, b, c Vector3) float32 {
+	return (b.X-a.X)*(c.Y-a.Y) - (b.Y-a.Y)*(c.X-a.X)
+}
+
+// samePoint checks whether two points are the same in XY
+func samePoint(a, b Vector3) bool {
+	return a.X == b.X && a.Y == b.Y
+}
+
+// appendWaypoint appends point to waypoints unless it's the same as the last waypoint
+func appendWaypoint(waypoints []Vector3, point Vector3) []Vector3 {
+	if len(waypoints) > 0 && waypoints[len(waypoints)-1] == point {
+		return waypoints
+	}
+
+	return append(waypoints, point)
+}
@@ -0,0 +1,62 @@
+/*
+	gonav - A Source Engine navigation mesh file parser written in Go.
+	Copyright (C) 2016  Matt Razza
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as published
+	by the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package gonav provides functionality related to CS:GO Nav Meshes
+package gonav
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSmoothStopsAtTwoWayDrop(t *testing.T) {
+	parser := Parser{Reader: bytes.NewReader(writeTestMesh(t, newTestMesh(3), 16))}
+	mesh, err := parser.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Area 2 ends up 30 units above area 3, too high to step back up, so it is linked back by hand
+	if err := mesh.MoveArea(mesh.Areas[2], Vector3{0, 0, 30}); err != nil {
+		t.Fatal(err)
+	}
+
+	mesh.RebuildConnections(DefaultConnectionOptions())
+
+	if err := mesh.Connect(mesh.Areas[3], mesh.Areas[2], NavDirectionWest); err != nil {
+		t.Fatal(err)
+	}
+
+	connections := mesh.Areas[2].GetConnectionsTo(mesh.Areas[3])
+	if len(connections) != 1 || connections[0].Kind != NavConnectionBidirectional {
+		t.Fatalf("Expected one bidirectional connection from area 2 to area 3, got %v.", connections)
+	}
+
+	path := Path{Nodes: []*PathNode{
+		{Area: mesh.Areas[2]},
+		{Area: mesh.Areas[3], Connection: connections[0]}}}
+	waypoints := path.Smooth(mesh.Areas[2].GetCenter(), mesh.Areas[3].GetCenter())
+
+	if len(waypoints) != 4 {
+		t.Fatalf("Expected the drop to add waypoints on both sides of the edge, got %v.", waypoints)
+	}
+
+	if edge := waypoints[1]; edge.X != mesh.Areas[2].SouthEast.X || edge.Z < mesh.Areas[3].NorthWest.Z+18 {
+		t.Errorf("Expected a waypoint at the top of the drop, got %v.", edge)
+	}
+}
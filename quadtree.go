@@ -26,6 +26,12 @@ import (
 
 const preferredNodeCapacity int = 4
 
+// errAreaNotContained is returned when inserting an area into a node that cannot hold it
+var errAreaNotContained = errors.New("Specified area cannot be added because it is not fully contained within this node.")
+
+// minimumNodeSize is the smallest width or height a node may be sub divided down to
+const minimumNodeSize float32 = 1
+
 // quadTreeNode represents a single node in a QuadTree (possibly the root node, possibly not)
 type quadTreeNode struct {
 	Areas          []*NavArea    // The NavAreas contained in this node
@@ -37,10 +43,87 @@ type quadTreeNode struct {
 	SouthEast      *quadTreeNode // The south east sub node (nil if not sub-divided)
 }
 
+// defaultQuadTreeExtent is the half-width of the root node used when there are no areas to size it from
+const defaultQuadTreeExtent float32 = 16384
+
+// newQuadTree creates an empty quad tree root node whose bounds fit the specified areas.
+// Areas whose bounds are not finite are ignored when sizing the node.
+func newQuadTree(areas []*NavArea) *quadTreeNode {
+	min := Vector3{float32(math.Inf(1)), float32(math.Inf(1)), 0}
+	max := Vector3{float32(math.Inf(-1)), float32(math.Inf(-1)), 0}
+
+	for _, currArea := range areas {
+		if !currArea.hasFiniteBounds() {
+			continue
+		}
+
+		min.X = float32(math.Min(float64(min.X), math.Min(float64(currArea.NorthWest.X), float64(currArea.SouthEast.X))))
+		min.Y = float32(math.Min(float64(min.Y), math.Min(float64(currArea.NorthWest.Y), float64(currArea.SouthEast.Y))))
+		max.X = float32(math.Max(float64(max.X), math.Max(float64(currArea.NorthWest.X), float64(currArea.SouthEast.X))))
+		max.Y = float32(math.Max(float64(max.Y), math.Max(float64(currArea.NorthWest.Y), float64(currArea.SouthEast.Y))))
+	}
+
+	if min.X > max.X || min.Y > max.Y {
+		return &quadTreeNode{
+			NorthWestPoint: Vector3{-defaultQuadTreeExtent, -defaultQuadTreeExtent, 0},
+			SouthEastPoint: Vector3{defaultQuadTreeExtent, defaultQuadTreeExtent, 0}}
+	}
+
+	// Pad the bounds a little so areas on the edge are comfortably inside
+	min.X--
+	min.Y--
+	max.X++
+	max.Y++
+
+	return &quadTreeNode{NorthWestPoint: min, SouthEastPoint: max}
+}
+
+// grow creates a new root node twice the size of this one, extended towards the specified area.
+// This node becomes one of the sub nodes of the new root.
+func (node *quadTreeNode) grow(area *NavArea) *quadTreeNode {
+	width := node.SouthEastPoint.X - node.NorthWestPoint.X
+	height := node.SouthEastPoint.Y - node.NorthWestPoint.Y
+	growWest := area.NorthWest.X < node.NorthWestPoint.X
+	growNorth := area.NorthWest.Y < node.NorthWestPoint.Y
+
+	root := &quadTreeNode{NorthWestPoint: node.NorthWestPoint, SouthEastPoint: node.SouthEastPoint}
+	minX, midX, maxX := node.NorthWestPoint.X, node.SouthEastPoint.X, node.SouthEastPoint.X+width
+	minY, midY, maxY := node.NorthWestPoint.Y, node.SouthEastPoint.Y, node.SouthEastPoint.Y+height
+
+	if growWest {
+		minX, midX, maxX = node.NorthWestPoint.X-width, node.NorthWestPoint.X, node.SouthEastPoint.X
+	}
+
+	if growNorth {
+		minY, midY, maxY = node.NorthWestPoint.Y-height, node.NorthWestPoint.Y, node.SouthEastPoint.Y
+	}
+
+	root.NorthWestPoint = Vector3{minX, minY, 0}
+	root.SouthEastPoint = Vector3{maxX, maxY, 0}
+	root.NorthWest = &quadTreeNode{NorthWestPoint: Vector3{minX, minY, 0}, SouthEastPoint: Vector3{midX, midY, 0}}
+	root.NorthEast = &quadTreeNode{NorthWestPoint: Vector3{midX, minY, 0}, SouthEastPoint: Vector3{maxX, midY, 0}}
+	root.SouthWest = &quadTreeNode{NorthWestPoint: Vector3{minX, midY, 0}, SouthEastPoint: Vector3{midX, maxY, 0}}
+	root.SouthEast = &quadTreeNode{NorthWestPoint: Vector3{midX, midY, 0}, SouthEastPoint: Vector3{maxX, maxY, 0}}
+
+	// Swap the existing node in for the quadrant it occupies
+	switch {
+	case growWest && growNorth:
+		root.SouthEast = node
+	case growWest:
+		root.NorthEast = node
+	case growNorth:
+		root.SouthWest = node
+	default:
+		root.NorthWest = node
+	}
+
+	return root
+}
+
 // InsertArea inserts the specified NavArea into this quad tree
 func (node *quadTreeNode) InsertArea(area *NavArea) (*quadTreeNode, error) {
 	if !node.isAreaFullyContained(area) {
-		return nil, errors.New("Specified area cannot be added because it is not fully contained within this node.")
+		return nil, errAreaNotContained
 	}
 
 	if node.isSubDivided() {
@@ -57,7 +140,7 @@ func (node *quadTreeNode) InsertArea(area *NavArea) (*quadTreeNode, error) {
 			return node, nil
 		}
 	} else {
-		if len(node.Areas) >= preferredNodeCapacity {
+		if len(node.Areas) >= preferredNodeCapacity && node.canSubDivide() {
 			node.subDivide()
 			return node.InsertArea(area)
 		}
@@ -67,6 +150,31 @@ func (node *quadTreeNode) InsertArea(area *NavArea) (*quadTreeNode, error) {
 	}
 }
 
+// RemoveArea removes the specified NavArea from this quad tree; false if it could not be found.
+// The area must have the same bounds it had when it was inserted.
+func (node *quadTreeNode) RemoveArea(area *NavArea) bool {
+	if !node.isAreaFullyContained(area) {
+		return false
+	}
+
+	for i, currArea := range node.Areas {
+		if currArea == area {
+			node.Areas = append(node.Areas[:i], node.Areas[i+1:]...)
+			return true
+		}
+	}
+
+	if node.isSubDivided() {
+		for _, currNode := range []*quadTreeNode{node.NorthWest, node.NorthEast, node.SouthWest, node.SouthEast} {
+			if currNode.RemoveArea(area) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 // Finds the area that contains the specified point; nil if the area could not be found
 // The Z-value is used to find the closest area that contains the X and Y values
 // If allowBelow is true the area closest by Z that contains this point is returned
@@ -99,21 +207,39 @@ func (node *quadTreeNode) FindAreaByPoint(point Vector3, allowBelow bool) *NavAr
 	}
 
 	// If we're subdivided we need to recurse
+	// Points on the edge of a sub node may be in more than one of them
 	if node.isSubDivided() {
-		if currArea := node.NorthWest.FindAreaByPoint(point, allowBelow); currArea != nil {
-			updateBestArea(currArea)
-		} else if currArea := node.NorthEast.FindAreaByPoint(point, allowBelow); currArea != nil {
-			updateBestArea(currArea)
-		} else if currArea := node.SouthWest.FindAreaByPoint(point, allowBelow); currArea != nil {
-			updateBestArea(currArea)
-		} else if currArea := node.SouthEast.FindAreaByPoint(point, allowBelow); currArea != nil {
-			updateBestArea(currArea)
+		for _, currNode := range []*quadTreeNode{node.NorthWest, node.NorthEast, node.SouthWest, node.SouthEast} {
+			if currArea := currNode.FindAreaByPoint(point, allowBelow); currArea != nil {
+				updateBestArea(currArea)
+			}
 		}
 	}
 
 	return bestArea
 }
 
+// FindAreasInBox appends every area whose bounds overlap the specified box to the specified slice
+func (node *quadTreeNode) FindAreasInBox(northWest Vector3, southEast Vector3, areas []*NavArea) []*NavArea {
+	if node.NorthWestPoint.X > southEast.X || node.NorthWestPoint.Y > southEast.Y || node.SouthEastPoint.X < northWest.X || node.SouthEastPoint.Y < northWest.Y {
+		return areas
+	}
+
+	for _, currArea := range node.Areas {
+		if currArea.NorthWest.X <= southEast.X && currArea.NorthWest.Y <= southEast.Y && currArea.SouthEast.X >= northWest.X && currArea.SouthEast.Y >= northWest.Y {
+			areas = append(areas, currArea)
+		}
+	}
+
+	if node.isSubDivided() {
+		for _, currNode := range []*quadTreeNode{node.NorthWest, node.NorthEast, node.SouthWest, node.SouthEast} {
+			areas = currNode.FindAreasInBox(northWest, southEast, areas)
+		}
+	}
+
+	return areas
+}
+
 // containsPoint determines whether or not the specified point is contained in the node
 func (node *quadTreeNode) containsPoint(point Vector3) bool {
 	return node.NorthWestPoint.X <= point.X && node.NorthWestPoint.Y <= point.Y && node.SouthEastPoint.X >= point.X && node.SouthEastPoint.Y >= point.Y
@@ -130,10 +256,13 @@ func (node *quadTreeNode) subDivide() error {
 		return errors.New("Cannot subdivide already subdivided node.")
 	}
 
-	node.NorthWest = new(quadTreeNode)
-	node.NorthEast = new(quadTreeNode)
-	node.SouthWest = new(quadTreeNode)
-	node.SouthEast = new(quadTreeNode)
+	midX := (node.NorthWestPoint.X + node.SouthEastPoint.X) / 2
+	midY := (node.NorthWestPoint.Y + node.SouthEastPoint.Y) / 2
+
+	node.NorthWest = &quadTreeNode{NorthWestPoint: node.NorthWestPoint, SouthEastPoint: Vector3{midX, midY, 0}}
+	node.NorthEast = &quadTreeNode{NorthWestPoint: Vector3{midX, node.NorthWestPoint.Y, 0}, SouthEastPoint: Vector3{node.SouthEastPoint.X, midY, 0}}
+	node.SouthWest = &quadTreeNode{NorthWestPoint: Vector3{node.NorthWestPoint.X, midY, 0}, SouthEastPoint: Vector3{midX, node.SouthEastPoint.Y, 0}}
+	node.SouthEast = &quadTreeNode{NorthWestPoint: Vector3{midX, midY, 0}, SouthEastPoint: node.SouthEastPoint}
 
 	currAreas := node.Areas
 	node.Areas = nil
@@ -145,6 +274,18 @@ func (node *quadTreeNode) subDivide() error {
 	return nil
 }
 
+// hasFiniteBounds determines whether or not the bounds of this node are finite
+func (node *quadTreeNode) hasFiniteBounds() bool {
+	return isFinite(node.NorthWestPoint.X) && isFinite(node.NorthWestPoint.Y) &&
+		isFinite(node.SouthEastPoint.X) && isFinite(node.SouthEastPoint.Y)
+}
+
+// canSubDivide determines whether or not this node is large enough to be sub divided
+func (node *quadTreeNode) canSubDivide() bool {
+	return node.SouthEastPoint.X-node.NorthWestPoint.X >= 2*minimumNodeSize &&
+		node.SouthEastPoint.Y-node.NorthWestPoint.Y >= 2*minimumNodeSize
+}
+
 // isSubDivided determines whether or not this node is sub divided
 func (node *quadTreeNode) isSubDivided() bool {
 	return node.NorthWest != nil || node.NorthEast != nil || node.SouthWest != nil || node.SouthEast != nil
@@ -0,0 +1,306 @@
+/*
+	gonav - A Source Engine navigation mesh file parser written in Go.
+	Copyright (C) 2016  Matt Razza
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as published
+	by the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package gonav provides functionality related to CS:GO Nav Meshes
+package gonav
+
+import "fmt"
+
+// ValidationSeverity represents how serious a ValidationFinding is
+type ValidationSeverity int
+
+const (
+	// ValidationSeverityInfo is something worth knowing about that is often intentional
+	ValidationSeverityInfo ValidationSeverity = iota
+
+	// ValidationSeverityWarning is something that is probably a mistake but will not break searching the mesh
+	ValidationSeverityWarning
+
+	// ValidationSeverityError is something that will break searching or pathing through the mesh
+	ValidationSeverityError
+)
+
+// String converts a ValidationSeverity into a human readable string
+func (severity ValidationSeverity) String() string {
+	switch severity {
+	case ValidationSeverityInfo:
+		return "info"
+	case ValidationSeverityWarning:
+		return "warning"
+	case ValidationSeverityError:
+		return "error"
+	}
+
+	return fmt.Sprintf("ValidationSeverity(%d)", int(severity))
+}
+
+// ValidationKind identifies the problem described by a ValidationFinding
+type ValidationKind int
+
+const (
+	// ValidationKindDanglingConnection is a connection to an area that is not in the mesh
+	ValidationKindDanglingConnection ValidationKind = iota
+
+	// ValidationKindDanglingLadder is a ladder connection to a ladder that is not in the mesh,
+	// or a ladder connected to an area that is not in the mesh
+	ValidationKindDanglingLadder
+
+	// ValidationKindDanglingEncounterPath is an encounter path to or from an area that is not in the mesh
+	ValidationKindDanglingEncounterPath
+
+	// ValidationKindDanglingVisibility is a visible area, or an area visibility is inherited from, that is not in the mesh
+	ValidationKindDanglingVisibility
+
+	// ValidationKindOneWayConnection is a connection with no connection back from the target area
+	ValidationKindOneWayConnection
+
+	// ValidationKindInvertedBounds is an area whose north-west point is south or east of its south-east point
+	ValidationKindInvertedBounds
+
+	// ValidationKindDegenerateBounds is an area with no size, non-finite coordinates or too few corners
+	ValidationKindDegenerateBounds
+
+	// ValidationKindDuplicateID is an ID used by more than one area or ladder, or an entry stored under the wrong ID
+	ValidationKindDuplicateID
+
+	// ValidationKindUnindexedArea is an area that is missing from the quad tree
+	ValidationKindUnindexedArea
+
+	// ValidationKindEmptyPlace is a place that contains no areas
+	ValidationKindEmptyPlace
+
+	// ValidationKindPartialMesh is a mesh the parser skipped parts of, such as the ladders of a CS2 mesh
+	ValidationKindPartialMesh
+)
+
+// String converts a ValidationKind into a human readable string
+func (kind ValidationKind) String() string {
+	switch kind {
+	case ValidationKindDanglingConnection:
+		return "dangling connection"
+	case ValidationKindDanglingLadder:
+		return "dangling ladder"
+	case ValidationKindDanglingEncounterPath:
+		return "dangling encounter path"
+	case ValidationKindDanglingVisibility:
+		return "dangling visibility"
+	case ValidationKindOneWayConnection:
+		return "one-way connection"
+	case ValidationKindInvertedBounds:
+		return "inverted bounds"
+	case ValidationKindDegenerateBounds:
+		return "degenerate bounds"
+	case ValidationKindDuplicateID:
+		return "duplicate ID"
+	case ValidationKindUnindexedArea:
+		return "unindexed area"
+	case ValidationKindEmptyPlace:
+		return "empty place"
+	case ValidationKindPartialMesh:
+		return "partial mesh"
+	}
+
+	return fmt.Sprintf("ValidationKind(%d)", int(kind))
+}
+
+// ValidationFinding describes a single problem found by NavMesh.Validate
+type ValidationFinding struct {
+	Severity  ValidationSeverity // How serious the problem is
+	Kind      ValidationKind     // What the problem is
+	AreaIDs   []uint32           // IDs of the areas involved, the area the problem was found on first
+	LadderIDs []uint32           // IDs of the ladders involved
+	PlaceIDs  []uint32           // IDs of the places involved
+	Message   string             // Description of the problem
+}
+
+// String converts a ValidationFinding into a human readable string
+func (finding ValidationFinding) String() string {
+	return fmt.Sprintf("%v: %v: %v", finding.Severity, finding.Kind, finding.Message)
+}
+
+// ValidationReport is the result of NavMesh.Validate
+type ValidationReport struct {
+	Findings []ValidationFinding // Every problem found, ordered by the ID of the area, ladder or place it was found on
+}
+
+// HasErrors determines whether or not any finding is an error
+func (report ValidationReport) HasErrors() bool {
+	return report.Count(ValidationSeverityError) > 0
+}
+
+// Count gets the number of findings with the specified severity
+func (report ValidationReport) Count(severity ValidationSeverity) int {
+	count := 0
+
+	for _, currFinding := range report.Findings {
+		if currFinding.Severity == severity {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Filter gets the findings of the specified kind
+func (report ValidationReport) Filter(kind ValidationKind) []ValidationFinding {
+	var findings []ValidationFinding
+
+	for _, currFinding := range report.Findings {
+		if currFinding.Kind == kind {
+			findings = append(findings, currFinding)
+		}
+	}
+
+	return findings
+}
+
+func (report *ValidationReport) add(severity ValidationSeverity, kind ValidationKind, format string, args ...interface{}) *ValidationFinding {
+	report.Findings = append(report.Findings, ValidationFinding{Severity: severity, Kind: kind, Message: fmt.Sprintf(format, args...)})
+	return &report.Findings[len(report.Findings)-1]
+}
+
+// Validate checks the integrity of this mesh and reports every problem found.
+// It only reads the IDs stored in the mesh, so it can be used whether or not the graph is connected.
+func (mesh *NavMesh) Validate() ValidationReport {
+	report := ValidationReport{}
+
+	if mesh.IsPartial {
+		report.add(ValidationSeverityWarning, ValidationKindPartialMesh, "Parts of the nav file were not understood and were skipped, so the mesh is incomplete.")
+	}
+
+	for _, currID := range mesh.duplicateAreaIDs {
+		report.add(ValidationSeverityError, ValidationKindDuplicateID, "Area ID %v was used by more than one area; only the last was kept.", currID).AreaIDs = []uint32{currID}
+	}
+
+	for _, currID := range mesh.duplicateLadderIDs {
+		report.add(ValidationSeverityError, ValidationKindDuplicateID, "Ladder ID %v was used by more than one ladder; only the last was kept.", currID).LadderIDs = []uint32{currID}
+	}
+
+	for _, currArea := range sortedAreas(mesh) {
+		mesh.validateArea(&report, currArea)
+	}
+
+	for _, currLadder := range sortedLadders(mesh) {
+		mesh.validateLadder(&report, currLadder)
+	}
+
+	for _, currPlace := range sortedPlaces(mesh) {
+		if len(currPlace.Areas) == 0 {
+			report.add(ValidationSeverityWarning, ValidationKindEmptyPlace, "Place %v (%v) contains no areas.", currPlace.ID, currPlace.Name).PlaceIDs = []uint32{currPlace.ID}
+		}
+	}
+
+	return report
+}
+
+func (mesh *NavMesh) validateArea(report *ValidationReport, area *NavArea) {
+	if stored := mesh.Areas[area.ID]; stored != area {
+		report.add(ValidationSeverityError, ValidationKindDuplicateID, "Area %v is not stored under its own ID.", area.ID).AreaIDs = []uint32{area.ID}
+	}
+
+	mesh.validateAreaBounds(report, area)
+
+	for _, currArea := range mesh.UnindexedAreas {
+		if currArea == area {
+			report.add(ValidationSeverityWarning, ValidationKindUnindexedArea, "Area %v is missing from the quad tree and cannot be found by position.", area.ID).AreaIDs = []uint32{area.ID}
+		}
+	}
+
+	for _, currConnection := range area.Connections {
+		target, ok := mesh.Areas[currConnection.TargetAreaID]
+
+		if !ok {
+			report.add(ValidationSeverityError, ValidationKindDanglingConnection, "Area %v connects to area %v which does not exist.", area.ID, currConnection.TargetAreaID).AreaIDs = []uint32{area.ID, currConnection.TargetAreaID}
+		} else if !target.hasConnectionTo(area.ID) {
+			report.add(ValidationSeverityInfo, ValidationKindOneWayConnection, "Area %v connects to area %v but not the other way around.", area.ID, target.ID).AreaIDs = []uint32{area.ID, target.ID}
+		}
+	}
+
+	for _, currConnection := range area.LadderConnections {
+		if _, ok := mesh.Ladders[currConnection.TargetID]; !ok {
+			finding := report.add(ValidationSeverityError, ValidationKindDanglingLadder, "Area %v connects to ladder %v which does not exist.", area.ID, currConnection.TargetID)
+			finding.AreaIDs = []uint32{area.ID}
+			finding.LadderIDs = []uint32{currConnection.TargetID}
+		}
+	}
+
+	for _, currPath := range area.EncounterPaths {
+		for _, currID := range []uint32{currPath.FromAreaID, currPath.ToAreaID} {
+			if _, ok := mesh.Areas[currID]; !ok {
+				report.add(ValidationSeverityError, ValidationKindDanglingEncounterPath, "Area %v has an encounter path through area %v which does not exist.", area.ID, currID).AreaIDs = []uint32{area.ID, currID}
+			}
+		}
+	}
+
+	for _, currVisible := range area.VisibleAreas {
+		if _, ok := mesh.Areas[currVisible.VisibleAreaID]; !ok {
+			report.add(ValidationSeverityError, ValidationKindDanglingVisibility, "Area %v can see area %v which does not exist.", area.ID, currVisible.VisibleAreaID).AreaIDs = []uint32{area.ID, currVisible.VisibleAreaID}
+		}
+	}
+
+	if inheritID := area.InheritVisibilityFromAreaID; inheritID != 0 {
+		if _, ok := mesh.Areas[inheritID]; !ok {
+			report.add(ValidationSeverityError, ValidationKindDanglingVisibility, "Area %v inherits visibility from area %v which does not exist.", area.ID, inheritID).AreaIDs = []uint32{area.ID, inheritID}
+		}
+	}
+}
+
+func (mesh *NavMesh) validateAreaBounds(report *ValidationReport, area *NavArea) {
+	points := append([]Vector3{area.NorthWest, area.SouthEast}, area.Corners...)
+
+	for _, currPoint := range points {
+		if !isFinite(currPoint.X) || !isFinite(currPoint.Y) || !isFinite(currPoint.Z) {
+			report.add(ValidationSeverityError, ValidationKindDegenerateBounds, "Area %v has coordinates that are not finite.", area.ID).AreaIDs = []uint32{area.ID}
+			return
+		}
+	}
+
+	if area.NorthWest.X > area.SouthEast.X || area.NorthWest.Y > area.SouthEast.Y {
+		report.add(ValidationSeverityError, ValidationKindInvertedBounds, "Area %v has its north-west point %v south or east of its south-east point %v.", area.ID, area.NorthWest, area.SouthEast).AreaIDs = []uint32{area.ID}
+	} else if area.IsPolygon() && len(area.Corners) < 3 {
+		report.add(ValidationSeverityError, ValidationKindDegenerateBounds, "Area %v is a polygon with only %v corners.", area.ID, len(area.Corners)).AreaIDs = []uint32{area.ID}
+	} else if area.NorthWest.X == area.SouthEast.X || area.NorthWest.Y == area.SouthEast.Y {
+		report.add(ValidationSeverityWarning, ValidationKindDegenerateBounds, "Area %v has no width or no height.", area.ID).AreaIDs = []uint32{area.ID}
+	}
+}
+
+func (mesh *NavMesh) validateLadder(report *ValidationReport, ladder *NavLadder) {
+	if stored := mesh.Ladders[ladder.ID]; stored != ladder {
+		report.add(ValidationSeverityError, ValidationKindDuplicateID, "Ladder %v is not stored under its own ID.", ladder.ID).LadderIDs = []uint32{ladder.ID}
+	}
+
+	// An area ID of 0 means nothing is connected at that position
+	for _, currID := range []uint32{ladder.TopForwardAreaID, ladder.TopLeftAreaID, ladder.TopRightAreaID, ladder.TopBehindAreaID, ladder.BottomAreaID} {
+		if _, ok := mesh.Areas[currID]; currID != 0 && !ok {
+			finding := report.add(ValidationSeverityError, ValidationKindDanglingLadder, "Ladder %v connects to area %v which does not exist.", ladder.ID, currID)
+			finding.AreaIDs = []uint32{currID}
+			finding.LadderIDs = []uint32{ladder.ID}
+		}
+	}
+}
+
+// hasConnectionTo determines whether or not this area has a connection to the area with the specified ID
+func (area *NavArea) hasConnectionTo(id uint32) bool {
+	for _, currConnection := range area.Connections {
+		if currConnection.TargetAreaID == id {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,99 @@
+/*
+	gonav - A Source Engine navigation mesh file parser written in Go.
+	Copyright (C) 2016  Matt Razza
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as published
+	by the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package gonav provides functionality related to CS:GO Nav Meshes
+package gonav
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestValidateReportsEveryKind(t *testing.T) {
+	parser := Parser{Reader: bytes.NewReader(writeTestMesh(t, newTestMesh(3), 16))}
+	mesh, err := parser.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report := mesh.Validate(); len(report.Findings) != 0 {
+		t.Fatalf("Expected no findings on the test mesh, got %v.", report.Findings)
+	}
+
+	// Break the mesh in every way Validate knows about
+	mesh.Areas[2].Connections = append(mesh.Areas[2].Connections, &NavConnection{SourceArea: mesh.Areas[2], TargetAreaID: 100})
+	mesh.Areas[3].LadderConnections = append(mesh.Areas[3].LadderConnections, &NavLadderConnection{SourceArea: mesh.Areas[3], TargetID: 200})
+	mesh.Ladders[1].TopLeftAreaID = 101
+	mesh.Areas[4].EncounterPaths = append(mesh.Areas[4].EncounterPaths, &NavEncounterPath{FromAreaID: 1, ToAreaID: 102})
+	mesh.Areas[6].VisibleAreas = append(mesh.Areas[6].VisibleAreas, &NavVisibleArea{VisibleAreaID: 103})
+	mesh.Areas[6].InheritVisibilityFromAreaID = 104
+	mesh.Disconnect(mesh.Areas[8], mesh.Areas[7])
+	mesh.Areas[7].NorthWest.X = mesh.Areas[7].SouthEast.X + 1
+	mesh.Areas[8].SouthEast.Y = mesh.Areas[8].NorthWest.Y
+	mesh.UnindexedAreas = append(mesh.UnindexedAreas, mesh.Areas[9])
+	mesh.duplicateAreaIDs = append(mesh.duplicateAreaIDs, 5)
+	mesh.Places[3] = &NavPlace{ID: 3, Name: "Empty"}
+
+	report := mesh.Validate()
+
+	for _, test := range []struct {
+		kind      ValidationKind
+		severity  ValidationSeverity
+		areaIDs   []uint32
+		ladderIDs []uint32
+		placeIDs  []uint32
+	}{
+		{ValidationKindDuplicateID, ValidationSeverityError, []uint32{5}, nil, nil},
+		{ValidationKindDanglingConnection, ValidationSeverityError, []uint32{2, 100}, nil, nil},
+		{ValidationKindDanglingLadder, ValidationSeverityError, []uint32{3}, []uint32{200}, nil},
+		{ValidationKindDanglingEncounterPath, ValidationSeverityError, []uint32{4, 102}, nil, nil},
+		{ValidationKindDanglingVisibility, ValidationSeverityError, []uint32{6, 103}, nil, nil},
+		{ValidationKindDanglingVisibility, ValidationSeverityError, []uint32{6, 104}, nil, nil},
+		{ValidationKindOneWayConnection, ValidationSeverityInfo, []uint32{7, 8}, nil, nil},
+		{ValidationKindInvertedBounds, ValidationSeverityError, []uint32{7}, nil, nil},
+		{ValidationKindDegenerateBounds, ValidationSeverityWarning, []uint32{8}, nil, nil},
+		{ValidationKindUnindexedArea, ValidationSeverityWarning, []uint32{9}, nil, nil},
+		{ValidationKindDanglingLadder, ValidationSeverityError, []uint32{101}, []uint32{1}, nil},
+		{ValidationKindEmptyPlace, ValidationSeverityWarning, nil, nil, []uint32{3}},
+	} {
+		found := false
+
+		for _, currFinding := range report.Filter(test.kind) {
+			if reflect.DeepEqual(currFinding.AreaIDs, test.areaIDs) && reflect.DeepEqual(currFinding.LadderIDs, test.ladderIDs) && reflect.DeepEqual(currFinding.PlaceIDs, test.placeIDs) {
+				found = true
+
+				if currFinding.Severity != test.severity {
+					t.Errorf("%v is a %v, expected a %v.", currFinding, currFinding.Severity, test.severity)
+				}
+			}
+		}
+
+		if !found {
+			t.Errorf("No %v finding for areas %v, ladders %v and places %v.", test.kind, test.areaIDs, test.ladderIDs, test.placeIDs)
+		}
+	}
+
+	if len(report.Findings) != 12 {
+		t.Errorf("Expected 12 findings, got %v: %v", len(report.Findings), report.Findings)
+	}
+
+	if !report.HasErrors() || report.Count(ValidationSeverityWarning) != 3 || report.Count(ValidationSeverityInfo) != 1 {
+		t.Errorf("Findings were not counted by severity: %v", report.Findings)
+	}
+}
@@ -26,6 +26,11 @@ type Vector3 struct {
 	X, Y, Z float32 // The X, Y, and Z coordinates of the vector
 }
 
+// isFinite determines whether or not the specified value is neither infinite nor NaN
+func isFinite(value float32) bool {
+	return !math.IsInf(float64(value), 0) && !math.IsNaN(float64(value))
+}
+
 // LengthSquared gets the square of the length of the Vector.
 // This operation is faster than Length().
 func (v *Vector3) LengthSquared() float32 {
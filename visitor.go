@@ -0,0 +1,86 @@
+/*
+	gonav - A Source Engine navigation mesh file parser written in Go.
+	Copyright (C) 2016  Matt Razza
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as published
+	by the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package gonav provides functionality related to CS:GO Nav Meshes
+package gonav
+
+// NavVisitor receives each part of a nav mesh as it is decoded by Parser.ParseStream.
+// Returning an error from any method stops the parse.
+type NavVisitor interface {
+	// VisitHeader is called once the version information is decoded. The mesh only holds the
+	// header fields; Places, HasUnnamedAreas and CustomData are filled in before the first area,
+	// and IsPartial once the parse is done.
+	VisitHeader(mesh *NavMesh) error
+
+	// VisitPlace is called for each place.
+	VisitPlace(place *NavPlace) error
+
+	// VisitArea is called for each area. Its Place is set, but place.Areas is not updated
+	// and none of its connections are resolved to other areas.
+	VisitArea(area *NavArea) error
+
+	// VisitLadder is called for each ladder. None of its areas are resolved.
+	VisitLadder(ladder *NavLadder) error
+}
+
+// meshBuilder is the NavVisitor used by Parse to build a full NavMesh
+type meshBuilder struct {
+	mesh *NavMesh
+}
+
+func (builder *meshBuilder) VisitHeader(mesh *NavMesh) error {
+	builder.mesh = mesh
+	mesh.Areas = make(map[uint32]*NavArea)
+	mesh.Ladders = make(map[uint32]*NavLadder)
+	return nil
+}
+
+func (builder *meshBuilder) VisitPlace(place *NavPlace) error {
+	return nil
+}
+
+func (builder *meshBuilder) VisitArea(area *NavArea) error {
+	if previous, ok := builder.mesh.Areas[area.ID]; ok {
+		builder.mesh.duplicateAreaIDs = append(builder.mesh.duplicateAreaIDs, area.ID)
+
+		if previous.Place != nil {
+			previous.Place.removeArea(previous)
+		}
+	} else {
+		builder.mesh.areaOrder = append(builder.mesh.areaOrder, area.ID)
+	}
+
+	builder.mesh.Areas[area.ID] = area
+
+	if area.Place != nil {
+		area.Place.Areas = append(area.Place.Areas, area)
+	}
+
+	return nil
+}
+
+func (builder *meshBuilder) VisitLadder(ladder *NavLadder) error {
+	if _, ok := builder.mesh.Ladders[ladder.ID]; ok {
+		builder.mesh.duplicateLadderIDs = append(builder.mesh.duplicateLadderIDs, ladder.ID)
+	} else {
+		builder.mesh.ladderOrder = append(builder.mesh.ladderOrder, ladder.ID)
+	}
+
+	builder.mesh.Ladders[ladder.ID] = ladder
+	return nil
+}
@@ -0,0 +1,434 @@
+/*
+	gonav - A Source Engine navigation mesh file parser written in Go.
+	Copyright (C) 2016  Matt Razza
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as published
+	by the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package gonav provides functionality related to CS:GO Nav Meshes
+package gonav
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// Writer provides support for writing .nav files.
+type Writer struct {
+	Writer       io.Writer
+	MajorVersion uint32 // The major version to write; the version of the NavMesh is used if zero
+}
+
+type writerError struct {
+	Message string
+	Error   error
+}
+
+// countingWriter tracks the number of bytes written to the underlying writer
+type countingWriter struct {
+	Writer io.Writer
+	Count  int64
+}
+
+func (cw *countingWriter) Write(data []byte) (int, error) {
+	n, err := cw.Writer.Write(data)
+	cw.Count += int64(n)
+	return n, err
+}
+
+// WriteTo writes this NavMesh to the specified writer in the .nav format using the
+// MajorVersion of the mesh. It returns the number of bytes written.
+// WriteTo takes no version so that NavMesh implements io.WriterTo; to write another
+// version, set MajorVersion on a Writer and call its Write method instead.
+func (mesh *NavMesh) WriteTo(w io.Writer) (int64, error) {
+	counter := countingWriter{Writer: w}
+	writer := Writer{Writer: &counter}
+	err := writer.Write(mesh)
+
+	return counter.Count, err
+}
+
+// Write writes the specified NavMesh to the Writer supplied to this instance.
+// The output uses the same layout Parse consumes. Areas and ladders are written in the order they were
+// parsed, followed by any added since in order of ID, so a parsed mesh is written back byte for byte.
+// Data the requested version does not support is dropped and data it requires that the mesh
+// does not have is written as zero. Attribute flags are the exception: an area with flags outside
+// NavAttributeMask for the version is an error rather than silently losing them.
+func (w *Writer) Write(mesh *NavMesh) (err error) {
+	if w.Writer == nil {
+		return errors.New("This writer instance does not have a Writer.")
+	}
+
+	version := w.MajorVersion
+	if version == 0 {
+		version = mesh.MajorVersion
+	}
+
+	if version < 6 || version > 16 {
+		return fmt.Errorf("Major version %v cannot be written. Polygonal (CS2) meshes are not supported.", version)
+	}
+
+	// Handle the recover scenerio for panics from our writer functions
+	defer func() {
+		if r := recover(); r != nil {
+			msg, ok := r.(writerError)
+
+			if ok {
+				err = fmt.Errorf("Unexpected error when writing: %s.\nParent error: %v.", msg.Message, msg.Error)
+			} else {
+				panic(r)
+			}
+		}
+	}()
+
+	// Header
+	w.write(uint32(0xFEEDFACE))
+	w.write(version)
+
+	if version >= 10 {
+		w.write(mesh.MinorVersion)
+	}
+
+	w.write(mesh.BSPSize)
+
+	if version >= 14 {
+		w.write(mesh.IsMeshAnalyzed)
+	}
+
+	// Places are stored by position, so their IDs are reassigned in order
+	places := sortedPlaces(mesh)
+	placeIDs := make(map[*NavPlace]uint16)
+
+	if len(places) > math.MaxUint16 {
+		panic(writerError{"Too many places", nil})
+	}
+
+	w.write(uint16(len(places)))
+
+	for i, currPlace := range places {
+		placeIDs[currPlace] = uint16(i + 1)
+
+		// A name must have at least its terminator, so empty names are always terminated
+		terminated := !currPlace.unterminated || currPlace.Name == ""
+		nameLength := len(currPlace.Name) + 1
+		if !terminated {
+			nameLength--
+		}
+
+		if nameLength > math.MaxUint16 {
+			panic(writerError{fmt.Sprintf("Place name is too long for place %v", currPlace.ID), nil})
+		}
+
+		w.write(uint16(nameLength))
+		w.writeBytes([]byte(currPlace.Name))
+
+		if terminated {
+			w.write(byte(0))
+		}
+	}
+
+	if version > 11 {
+		w.write(mesh.HasUnnamedAreas)
+	}
+
+	// Game-specific mesh data, encoded for the version being written rather than the one the mesh was read as
+	profile := profileOrDefault(mesh.Profile)
+	header := *mesh
+	header.MajorVersion = version
+
+	if version < 10 {
+		header.MinorVersion = 0
+	}
+
+	w.writeCustom(profile.EncodeMeshData(w.Writer, &header))
+
+	// Areas
+	areas := orderedAreas(mesh)
+	w.write(uint32(len(areas)))
+
+	for _, currArea := range areas {
+		w.writeArea(currArea, version, placeIDs)
+		w.writeCustom(profile.EncodeAreaData(w.Writer, &header, currArea))
+	}
+
+	// Ladders
+	ladders := orderedLadders(mesh)
+	w.write(uint32(len(ladders)))
+
+	for _, currLadder := range ladders {
+		w.write(currLadder.ID)
+		w.write(currLadder.Width)
+		w.write(currLadder.Top)
+		w.write(currLadder.Bottom)
+		w.write(currLadder.Length)
+		w.write(currLadder.Direction)
+		w.write(currLadder.TopForwardAreaID)
+		w.write(currLadder.TopLeftAreaID)
+		w.write(currLadder.TopRightAreaID)
+		w.write(currLadder.TopBehindAreaID)
+		w.write(currLadder.BottomAreaID)
+	}
+
+	return nil
+}
+
+func (w *Writer) writeArea(area *NavArea, version uint32, placeIDs map[*NavPlace]uint16) {
+	w.write(area.ID)
+
+	if lost := area.Attributes() &^ NavAttributeMask(version); lost != 0 {
+		panic(writerError{fmt.Sprintf("Attribute flags %v of area %v cannot be stored in version %v", lost, area.ID, version), nil})
+	}
+
+	if version <= 8 {
+		w.write(byte(area.Flags))
+	} else if version < 13 {
+		w.write(uint16(area.Flags))
+	} else {
+		w.write(area.Flags)
+	}
+
+	w.write(area.NorthWest)
+	w.write(area.SouthEast)
+	w.write(area.NorthEastZ)
+	w.write(area.SouthWestZ)
+
+	// Connections are grouped by direction
+	for direction := NavDirection(0); direction < NavDirectionMax; direction++ {
+		var connectionCount uint32
+
+		for _, currConnection := range area.Connections {
+			if currConnection.Direction == direction {
+				connectionCount++
+			}
+		}
+
+		w.write(connectionCount)
+
+		for _, currConnection := range area.Connections {
+			if currConnection.Direction == direction {
+				w.write(currConnection.TargetAreaID)
+			}
+		}
+	}
+
+	// Hiding spots
+	w.writeCount(len(area.HidingSpots), "hiding spots", area.ID)
+
+	for _, currSpot := range area.HidingSpots {
+		w.write(currSpot.ID)
+		w.write(currSpot.Location)
+		w.write(byte(currSpot.Flags))
+	}
+
+	// Approach areas
+	if version < 15 {
+		w.writeCount(len(area.ApproachAreas), "approach areas", area.ID)
+
+		for _, currApproach := range area.ApproachAreas {
+			w.write(currApproach.HereAreaID)
+			w.write(currApproach.PrevAreaID)
+			w.write(currApproach.PrevToHereHow)
+			w.write(currApproach.NextAreaID)
+			w.write(currApproach.HereToNextHow)
+		}
+	}
+
+	// Encounter paths
+	w.write(uint32(len(area.EncounterPaths)))
+
+	for _, currPath := range area.EncounterPaths {
+		w.write(currPath.FromAreaID)
+		w.write(currPath.FromDirection)
+		w.write(currPath.ToAreaID)
+		w.write(currPath.ToDirection)
+		w.writeCount(len(currPath.Spots), "encounter spots", area.ID)
+
+		for _, currSpot := range currPath.Spots {
+			w.write(currSpot.OrderID)
+			w.write(byte(math.Round(math.Max(0, math.Min(1, float64(currSpot.ParametricDistiance))) * 255)))
+		}
+	}
+
+	// Place
+	w.write(placeIDs[area.Place])
+
+	// Ladders are grouped by direction
+	for direction := NavLadderDirection(0); direction < NavLadderDirectionMax; direction++ {
+		var connectionCount uint32
+
+		for _, currConnection := range area.LadderConnections {
+			if currConnection.Direction == direction {
+				connectionCount++
+			}
+		}
+
+		w.write(connectionCount)
+
+		for _, currConnection := range area.LadderConnections {
+			if currConnection.Direction == direction {
+				w.write(currConnection.TargetID)
+			}
+		}
+	}
+
+	// Occupy times
+	w.write(area.EarliestOccupyTimeFirstTeam)
+	w.write(area.EarliestOccupyTimeSecondTeam)
+
+	// Light intensity
+	if version >= 11 {
+		w.write(area.NorthWestLightIntensity)
+		w.write(area.NorthEastLightIntensity)
+		w.write(area.SouthEastLightIntensity)
+		w.write(area.SouthWestLightIntensity)
+	}
+
+	// Visible areas
+	if version >= 16 {
+		w.write(uint32(len(area.VisibleAreas)))
+
+		for _, currVisible := range area.VisibleAreas {
+			w.write(currVisible.VisibleAreaID)
+			w.write(byte(currVisible.Attributes))
+		}
+	}
+
+	w.write(area.InheritVisibilityFromAreaID)
+}
+
+// writeCount writes a single byte count, failing if the count does not fit
+func (w *Writer) writeCount(count int, name string, areaID uint32) {
+	if count > math.MaxUint8 {
+		panic(writerError{fmt.Sprintf("Too many %s in area %v", name, areaID), nil})
+	}
+
+	w.write(byte(count))
+}
+
+func (w *Writer) write(data interface{}) {
+	switch t := data.(type) {
+	case Vector3:
+		w.write(t.X)
+		w.write(t.Y)
+		w.write(t.Z)
+
+	case bool:
+		if t {
+			w.write(byte(1))
+		} else {
+			w.write(byte(0))
+		}
+
+	case NavDirection:
+		w.write(byte(t))
+
+	case NavLadderDirection:
+		w.write(uint32(t))
+
+	default:
+		err := binary.Write(w.Writer, binary.LittleEndian, t)
+
+		if err != nil {
+			panic(writerError{"Failed to write data", err})
+		}
+	}
+}
+
+func (w *Writer) writeCustom(err error) {
+	if err != nil {
+		panic(writerError{"Failed to write custom data", err})
+	}
+}
+
+func (w *Writer) writeBytes(data []byte) {
+	if _, err := w.Writer.Write(data); err != nil {
+		panic(writerError{"Failed to write byte data", err})
+	}
+}
+
+func sortedPlaces(mesh *NavMesh) []*NavPlace {
+	places := make([]*NavPlace, 0, len(mesh.Places))
+	for _, currPlace := range mesh.Places {
+		places = append(places, currPlace)
+	}
+
+	sort.Slice(places, func(i, j int) bool { return places[i].ID < places[j].ID })
+	return places
+}
+
+func sortedAreas(mesh *NavMesh) []*NavArea {
+	areas := make([]*NavArea, 0, len(mesh.Areas))
+	for _, currArea := range mesh.Areas {
+		areas = append(areas, currArea)
+	}
+
+	sort.Slice(areas, func(i, j int) bool { return areas[i].ID < areas[j].ID })
+	return areas
+}
+
+// orderedAreas gets the areas of the mesh in the order they were parsed, followed by the rest in order of ID
+func orderedAreas(mesh *NavMesh) []*NavArea {
+	areas := make([]*NavArea, 0, len(mesh.Areas))
+	written := make(map[*NavArea]bool, len(mesh.Areas))
+
+	for _, currID := range mesh.areaOrder {
+		if currArea, ok := mesh.Areas[currID]; ok && !written[currArea] {
+			areas = append(areas, currArea)
+			written[currArea] = true
+		}
+	}
+
+	for _, currArea := range sortedAreas(mesh) {
+		if !written[currArea] {
+			areas = append(areas, currArea)
+		}
+	}
+
+	return areas
+}
+
+// orderedLadders gets the ladders of the mesh in the order they were parsed, followed by the rest in order of ID
+func orderedLadders(mesh *NavMesh) []*NavLadder {
+	ladders := make([]*NavLadder, 0, len(mesh.Ladders))
+	written := make(map[*NavLadder]bool, len(mesh.Ladders))
+
+	for _, currID := range mesh.ladderOrder {
+		if currLadder, ok := mesh.Ladders[currID]; ok && !written[currLadder] {
+			ladders = append(ladders, currLadder)
+			written[currLadder] = true
+		}
+	}
+
+	for _, currLadder := range sortedLadders(mesh) {
+		if !written[currLadder] {
+			ladders = append(ladders, currLadder)
+		}
+	}
+
+	return ladders
+}
+
+func sortedLadders(mesh *NavMesh) []*NavLadder {
+	ladders := make([]*NavLadder, 0, len(mesh.Ladders))
+	for _, currLadder := range mesh.Ladders {
+		ladders = append(ladders, currLadder)
+	}
+
+	sort.Slice(ladders, func(i, j int) bool { return ladders[i].ID < ladders[j].ID })
+	return ladders
+}
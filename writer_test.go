@@ -0,0 +1,171 @@
+/*
+	gonav - A Source Engine navigation mesh file parser written in Go.
+	Copyright (C) 2016  Matt Razza
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as published
+	by the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package gonav provides functionality related to CS:GO Nav Meshes
+package gonav
+
+import (
+	"bytes"
+	"testing"
+)
+
+// newTestMesh builds a mesh of size by size connected 25 unit areas with places, hiding spots, encounter
+// paths, visible areas and a ladder, so that every section of the file has something in it
+func newTestMesh(size int) *NavMesh {
+	mesh := &NavMesh{
+		MajorVersion:    16,
+		MinorVersion:    1,
+		BSPSize:         12345,
+		IsMeshAnalyzed:  true,
+		HasUnnamedAreas: true,
+		Places:          make(map[uint32]*NavPlace),
+		Areas:           make(map[uint32]*NavArea),
+		Ladders:         make(map[uint32]*NavLadder)}
+
+	for i, currName := range []string{"BombsiteA", "BombsiteB"} {
+		mesh.Places[uint32(i+1)] = &NavPlace{ID: uint32(i + 1), Name: currName}
+	}
+
+	areaID := func(x, y int) uint32 { return uint32(y*size + x + 1) }
+
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			z := float32(x)
+			area := &NavArea{
+				ID:                          areaID(x, y),
+				Flags:                       uint32(NavAttributeCrouch),
+				NorthWest:                   Vector3{float32(x * 25), float32(y * 25), z},
+				SouthEast:                   Vector3{float32(x*25 + 25), float32(y*25 + 25), z + 1},
+				NorthEastZ:                  z + 1,
+				SouthWestZ:                  z,
+				Place:                       mesh.Places[uint32((x+y)%3)],
+				EarliestOccupyTimeFirstTeam: 1.5,
+				NorthWestLightIntensity:     0.5,
+				InheritVisibilityFromAreaID: 0}
+
+			for direction, offset := range [][2]int{{0, -1}, {1, 0}, {0, 1}, {-1, 0}} {
+				otherX, otherY := x+offset[0], y+offset[1]
+
+				if otherX >= 0 && otherY >= 0 && otherX < size && otherY < size {
+					area.Connections = append(area.Connections, &NavConnection{
+						SourceArea:   area,
+						TargetAreaID: areaID(otherX, otherY),
+						Direction:    NavDirection(direction)})
+				}
+			}
+
+			if x == 1 && y == 1 {
+				area.HidingSpots = append(area.HidingSpots, &NavHidingSpot{
+					ID:       7,
+					Location: Vector3{area.NorthWest.X + 5, area.NorthWest.Y + 5, z},
+					Flags:    NavHidingSpotInCover | NavHidingSpotGoodSniperSpot})
+
+				area.EncounterPaths = append(area.EncounterPaths, &NavEncounterPath{
+					FromAreaID:    areaID(0, 1),
+					FromDirection: NavDirectionWest,
+					ToAreaID:      areaID(2, 1),
+					ToDirection:   NavDirectionEast,
+					Spots:         []*NavEncounterSpot{{OrderID: 7, ParametricDistiance: 128.0 / 255}}})
+			}
+
+			area.VisibleAreas = append(area.VisibleAreas, &NavVisibleArea{
+				VisibleAreaID: areaID(0, 0),
+				Attributes:    NavVisibilityCompletelyVisible})
+
+			mesh.Areas[area.ID] = area
+		}
+	}
+
+	if size > 1 {
+		mesh.Ladders[1] = &NavLadder{
+			ID:               1,
+			Width:            20,
+			Top:              Vector3{0, 0, 100},
+			Bottom:           Vector3{0, 0, 0},
+			Length:           100,
+			Direction:        NavLadderDirectionUp,
+			TopForwardAreaID: areaID(size-1, size-1),
+			BottomAreaID:     areaID(0, 0)}
+
+		bottom, top := mesh.Areas[areaID(0, 0)], mesh.Areas[areaID(size-1, size-1)]
+		bottom.LadderConnections = append(bottom.LadderConnections, &NavLadderConnection{SourceArea: bottom, TargetID: 1, Direction: NavLadderDirectionUp})
+		top.LadderConnections = append(top.LadderConnections, &NavLadderConnection{SourceArea: top, TargetID: 1, Direction: NavLadderDirectionDown})
+	}
+
+	return mesh
+}
+
+// writeTestMesh writes the specified mesh in the specified major version
+func writeTestMesh(tb testing.TB, mesh *NavMesh, version uint32) []byte {
+	var buffer bytes.Buffer
+	writer := Writer{Writer: &buffer, MajorVersion: version}
+
+	if err := writer.Write(mesh); err != nil {
+		tb.Fatal(err)
+	}
+
+	return buffer.Bytes()
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	source := newTestMesh(4)
+
+	// Areas out of ID order and place names without a null terminator must be written back as they were read
+	source.areaOrder = []uint32{16, 3, 9, 1, 2, 4, 5, 6, 7, 8, 10, 11, 12, 13, 14, 15}
+	source.Places[2].unterminated = true
+	source.Places[3] = &NavPlace{ID: 3, unterminated: true}
+
+	for version := uint32(6); version <= 16; version++ {
+		data := writeTestMesh(t, source, version)
+		parser := Parser{Reader: bytes.NewReader(data)}
+		mesh, err := parser.Parse()
+
+		if err != nil {
+			t.Fatalf("Version %v: %v", version, err)
+		}
+
+		if written := writeTestMesh(t, &mesh, version); !bytes.Equal(written, data) {
+			t.Fatalf("Version %v: wrote %v bytes that differ from the %v bytes parsed.", version, len(written), len(data))
+		}
+	}
+}
+
+func TestWriteRejectsLostFlags(t *testing.T) {
+	for _, currCase := range []struct {
+		flags   uint32
+		version uint32
+		valid   bool
+	}{
+		{0xFF, 8, true},
+		{0x100, 8, false},
+		{0xFFFF, 12, true},
+		{0x10000, 12, false},
+		{0xFFFFFFFF, 13, true},
+	} {
+		mesh := newTestMesh(2)
+		mesh.Areas[3].Flags = currCase.flags
+
+		var buffer bytes.Buffer
+		writer := Writer{Writer: &buffer, MajorVersion: currCase.version}
+		err := writer.Write(mesh)
+
+		if valid := err == nil; valid != currCase.valid {
+			t.Errorf("Writing flags 0x%x in version %v returned %v.", currCase.flags, currCase.version, err)
+		}
+	}
+}